@@ -0,0 +1,135 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// archivedFile is a file written by create and its name in the --archive.
+// Shares files are at the top of the archive, QR codes in qr/ and PDF sheets
+// in pdf/.
+type archivedFile struct {
+	path string
+	name string
+}
+
+// writeArchive packs everything written into --archive, then removes the
+// loose files unless --keep-files is given. The archive only depends on the
+// files, their order and the time of the run.
+func (g *gsssa) writeArchive() {
+	seen := make(map[string]bool)
+	for _, f := range g.archived {
+		if seen[f.name] {
+			fmt.Fprintf(os.Stderr, "Two of the files would both be \"%s\" in the archive. Give the shares files different names.\n", f.name)
+			os.Exit(1)
+		}
+		seen[f.name] = true
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !g.forceOverwrite {
+		flags |= os.O_EXCL
+	}
+	out, err := os.OpenFile(g.archive, flags, 0600)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	zw := gzip.NewWriter(out)
+	tw := tar.NewWriter(zw)
+	for _, f := range g.archived {
+		data, err := ioutil.ReadFile(f.path)
+		if err == nil {
+			err = tw.WriteHeader(&tar.Header{
+				Name:    f.name,
+				Mode:    0600,
+				Size:    int64(len(data)),
+				ModTime: g.created.Truncate(1e9),
+			})
+		}
+		if err == nil {
+			_, err = tw.Write(data)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Can't add \"%s\" to the archive: %v\n", f.path, err)
+			os.Exit(1)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := zw.Close(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := out.Close(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if g.keepFiles {
+		fmt.Fprintf(g.messages(), "Packed %d files into \"%s\".\n", len(g.archived), g.archive)
+		return
+	}
+	for _, f := range g.archived {
+		if err := os.Remove(f.path); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+	// The directories made for the QR codes and PDF sheets go too, if
+	// nothing else is in them.
+	for _, dir := range []string{g.qrDir, g.pdfDir} {
+		if len(dir) > 0 {
+			os.Remove(dir)
+		}
+	}
+	fmt.Fprintf(g.messages(), "Packed %d files into \"%s\" and removed them. Reveal with -f %s:<file>.\n", len(g.archived), g.archive, g.archive)
+}
+
+// readSharesFile reads the shares file given with -f. "archive.tar.gz:name" is
+// the file name in an archive written with --archive.
+func readSharesFile(filename string) ([]byte, error) {
+	for _, ext := range []string{".tar.gz:", ".tgz:"} {
+		if i := strings.Index(filename, ext); i > 0 {
+			return readFromArchive(filename[:i+len(ext)-1], filename[i+len(ext):])
+		}
+	}
+	return ioutil.ReadFile(filename)
+}
+
+// readFromArchive returns the file name from a tar.gz archive.
+func readFromArchive(archive, name string) ([]byte, error) {
+	f, err := os.Open(archive)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("can't read the archive \"%s\": %v", archive, err)
+	}
+	tr := tar.NewReader(zr)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("can't read the archive \"%s\": %v", archive, err)
+		}
+		if filepath.Clean(h.Name) == filepath.Clean(name) {
+			return ioutil.ReadAll(tr)
+		}
+	}
+	return nil, fmt.Errorf("there is no \"%s\" in the archive \"%s\"", name, archive)
+}
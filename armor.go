@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// With --format armor every share is a block framed like PGP armor:
+//
+//	-----BEGIN GSSSA SHARE 2/5-----
+//	Need: 3
+//
+//	<the bytes of the share in base64, 64 columns wide>
+//	=<CRC24 of the bytes in base64>
+//	-----END GSSSA SHARE 2/5-----
+//
+// The blocks don't depend on comments, blank lines or indentation, so they
+// can be rewrapped, indented and quoted like in an email reply and still be
+// revealed.
+const armorWidth = 64
+
+var (
+	armorBegin  = regexp.MustCompile(`^-----BEGIN GSSSA SHARE (\d+)/(\d+)-----$`)
+	armorEnd    = regexp.MustCompile(`^-----END GSSSA SHARE (\d+)/(\d+)-----$`)
+	armorHeader = regexp.MustCompile(`^([A-Za-z-]+): *(.*)$`)
+	armorCRC    = regexp.MustCompile(`^(.*)=([A-Za-z0-9+/]{4})$`)
+)
+
+// crc24 is the checksum of OpenPGP armor, RFC 4880 section 6.1.
+func crc24(data []byte) uint32 {
+	crc := uint32(0xb704ce)
+	for _, b := range data {
+		crc ^= uint32(b) << 16
+		for i := 0; i < 8; i++ {
+			crc <<= 1
+			if crc&0x1000000 != 0 {
+				crc ^= 0x1864cfb
+			}
+		}
+	}
+	return crc & 0xffffff
+}
+
+// crc24String is the CRC24 of data as written on the checksum line.
+func crc24String(data []byte) string {
+	crc := crc24(data)
+	return base64.StdEncoding.EncodeToString([]byte{byte(crc >> 16), byte(crc >> 8), byte(crc)})
+}
+
+// renderArmor renders the shares as armored blocks.
+func renderArmor(set *shareSet) string {
+	out := ""
+	for _, share := range set.shares {
+		marker := fmt.Sprintf("GSSSA SHARE %d/%d-----\n", share.number, set.amount)
+		out += "-----BEGIN " + marker
+		out += fmt.Sprintf("Need: %d\n", set.min)
+		if len(share.holder) > 0 {
+			out += "Holder: " + share.holder + "\n"
+		}
+		if len(share.label) > 0 {
+			out += "Label: " + share.label + "\n"
+		}
+		if set.checksum {
+			out += "Checksum: sha256-4\n"
+		}
+		if len(set.note) > 0 {
+			for _, line := range strings.Split(set.note, "\n") {
+				out += strings.TrimRight("Comment: "+line, " ") + "\n"
+			}
+		}
+		out += "\n"
+
+		body := base64.StdEncoding.EncodeToString(share.data)
+		for len(body) > armorWidth {
+			out += body[:armorWidth] + "\n"
+			body = body[armorWidth:]
+		}
+		out += body + "\n"
+		out += "=" + crc24String(share.data) + "\n"
+		out += "-----END " + marker + "\n"
+	}
+	return out
+}
+
+// isArmoredShares reports whether data holds armored share blocks.
+func isArmoredShares(data string) bool {
+	return strings.Contains(data, "-----BEGIN GSSSA SHARE ")
+}
+
+// parseArmoredShares reads the armored share blocks in data. Everything
+// outside of the blocks is ignored.
+func parseArmoredShares(data string) (*sharesFile, error) {
+	file := &sharesFile{}
+
+	var current *parsedShare
+	body := ""
+	for _, s := range strings.Split(data, "\n") {
+		s = unquoteLine(s)
+
+		if m := armorBegin.FindStringSubmatch(s); m != nil {
+			current = &parsedShare{}
+			current.number, _ = strconv.Atoi(m[1])
+			file.amount, _ = strconv.Atoi(m[2])
+			body = ""
+			continue
+		}
+		if current == nil {
+			continue
+		}
+
+		if armorEnd.MatchString(s) {
+			share, err := decodeArmorBody(current, body)
+			if err != nil {
+				return nil, err
+			}
+			file.shares = append(file.shares, *share)
+			current = nil
+			continue
+		}
+
+		if m := armorHeader.FindStringSubmatch(s); m != nil {
+			switch m[1] {
+			case "Need":
+				file.min, _ = strconv.Atoi(m[2])
+			case "Holder":
+				current.holder = m[2]
+			case "Label":
+				current.label = m[2]
+			case "Checksum":
+				file.hasChecksum = true
+			}
+			continue
+		}
+
+		if len(s) > 0 {
+			body += strings.Join(strings.Fields(s), "")
+			current.lines++
+		}
+	}
+
+	if current != nil {
+		return nil, fmt.Errorf("the armored block of share %d has no END line", current.number)
+	}
+	return file, nil
+}
+
+// unquoteLine is s without the ">" that quote it in an email reply, and
+// without the whitespace around it.
+func unquoteLine(s string) string {
+	return strings.TrimSpace(strings.TrimLeft(s, "> \t"))
+}
+
+// decodeArmorBody decodes the base64 body of an armored block and checks its
+// CRC24.
+func decodeArmorBody(share *parsedShare, body string) (*parsedShare, error) {
+	m := armorCRC.FindStringSubmatch(body)
+	if m == nil {
+		return nil, fmt.Errorf("the armored block of share %d has no checksum line", share.number)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(m[1])
+	if err != nil {
+		return nil, fmt.Errorf("can't decode the armored block of share %d: %v", share.number, err)
+	}
+	if crc24String(data) != m[2] {
+		return nil, fmt.Errorf("the checksum of the armored block of share %d doesn't match, it has been changed or damaged", share.number)
+	}
+
+	share.data = data
+	share.lines--
+	share.words = len(data)
+	return share, nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"runtime"
+)
+
+// clipboard copies to and pastes from the system clipboard with the command
+// line tools of the platform.
+type clipboard struct {
+	copyCmd  []string
+	pasteCmd []string
+}
+
+var errNoClipboard = errors.New("no clipboard is available. Install wl-clipboard (Wayland), xclip or xsel (X11), or reveal without --copy")
+
+// findClipboard returns the clipboard of this system.
+func findClipboard() (*clipboard, error) {
+	candidates := []struct {
+		env      string
+		copyCmd  []string
+		pasteCmd []string
+	}{
+		{"WAYLAND_DISPLAY", []string{"wl-copy"}, []string{"wl-paste", "--no-newline"}},
+		{"DISPLAY", []string{"xclip", "-selection", "clipboard", "-in"}, []string{"xclip", "-selection", "clipboard", "-out"}},
+		{"DISPLAY", []string{"xsel", "--clipboard", "--input"}, []string{"xsel", "--clipboard", "--output"}},
+	}
+
+	switch runtime.GOOS {
+	case "darwin":
+		return &clipboard{[]string{"pbcopy"}, []string{"pbpaste"}}, nil
+	case "windows":
+		return &clipboard{[]string{"clip"}, []string{"powershell", "-NoProfile", "-Command", "Get-Clipboard"}}, nil
+	}
+
+	for _, c := range candidates {
+		if len(os.Getenv(c.env)) == 0 {
+			continue
+		}
+		if _, err := exec.LookPath(c.copyCmd[0]); err == nil {
+			return &clipboard{c.copyCmd, c.pasteCmd}, nil
+		}
+	}
+	return nil, errNoClipboard
+}
+
+// write puts data on the clipboard.
+func (c *clipboard) write(data []byte) error {
+	cmd := exec.Command(c.copyCmd[0], c.copyCmd[1:]...)
+	cmd.Stdin = bytes.NewReader(data)
+	return cmd.Run()
+}
+
+// holds reports whether the clipboard still contains data. If the clipboard
+// can't be read it is assumed to.
+func (c *clipboard) holds(data []byte) bool {
+	out, err := exec.Command(c.pasteCmd[0], c.pasteCmd[1:]...).Output()
+	if err != nil {
+		return true
+	}
+	return bytes.Equal(bytes.TrimRight(out, "\r\n"), bytes.TrimRight(data, "\r\n"))
+}
+
+// clear empties the clipboard, but only if it still holds data so that
+// something copied in the meantime isn't thrown away.
+func (c *clipboard) clear(data []byte) error {
+	if !c.holds(data) {
+		return nil
+	}
+	return c.write(nil)
+}
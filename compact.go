@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// With --format compact every share is one self-describing line, e.g.
+//
+//	gsssa1:2of5:3:sha256-4: word word word ...
+//
+// for share 3 of 5 of which 2 are needed. The fields after the share number
+// name the encoding, if it isn't words, the language of the built-in word
+// list, if it isn't English, and the checksum, if there is one.
+const compactPrefix = "gsssa1:"
+
+// renderCompact renders the shares as one compact line each.
+func renderCompact(set *shareSet) string {
+	out := ""
+	for _, share := range set.shares {
+		fields := []string{fmt.Sprintf("%dof%d", set.min, set.amount), strconv.Itoa(share.number)}
+		if set.encoding != defaultEncoding {
+			fields = append(fields, set.encoding)
+		}
+		if len(set.lang) > 0 {
+			fields = append(fields, "lang="+set.lang)
+		}
+		if set.checksum {
+			fields = append(fields, "sha256-4")
+		}
+
+		var tokens []string
+		for _, line := range share.lines {
+			tokens = append(tokens, line...)
+		}
+		out += compactPrefix + strings.Join(fields, ":") + ": " + strings.Join(tokens, " ") + "\n"
+	}
+	return out
+}
+
+// isCompactShares reports whether data holds compact share lines.
+func isCompactShares(data string) bool {
+	for _, s := range strings.Split(data, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(s), compactPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseCompactShares reads the compact share lines in data. Other lines are
+// ignored, so several lines can be pasted into one file as they are.
+func (g *gsssa) parseCompactShares(data string) (*sharesFile, error) {
+	file := &sharesFile{}
+	for n, s := range strings.Split(data, "\n") {
+		s = strings.TrimSpace(s)
+		if !strings.HasPrefix(s, compactPrefix) {
+			continue
+		}
+
+		i := strings.Index(s, ": ")
+		if i < 0 {
+			return nil, fmt.Errorf("line %d is not a compact share, it should look like gsssa1:2of5:3: word word ...", n+1)
+		}
+		fields := strings.Split(s[len(compactPrefix):i], ":")
+
+		var min, amount, number int
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("line %d is not a compact share, it should look like gsssa1:2of5:3: word word ...", n+1)
+		}
+		if _, err := fmt.Sscanf(fields[0], "%dof%d", &min, &amount); err != nil {
+			return nil, fmt.Errorf("line %d doesn't say how many shares are needed: \"%s\"", n+1, fields[0])
+		}
+		number, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d doesn't say which share it is: \"%s\"", n+1, fields[1])
+		}
+		file.min, file.amount = min, amount
+
+		encoding := defaultEncoding
+		for _, f := range fields[2:] {
+			if f == "sha256-4" {
+				file.hasChecksum = true
+			} else if strings.HasPrefix(f, "lang=") {
+				g.useLanguage(strings.TrimPrefix(f, "lang="))
+			} else {
+				encoding = f
+			}
+		}
+		file.encoding = encoding
+		enc, err := g.shareEncoding(encoding)
+		if err != nil {
+			return nil, err
+		}
+
+		tokens := strings.Fields(s[i+2:])
+		name := shareName(number, len(file.shares)+1)
+		decoded, unknown, err := enc.decodeLine(tokens)
+		if err != nil {
+			file.malformed = append(file.malformed, positionError(err, name, 1, n+1).Error())
+			continue
+		}
+		for _, u := range placeUnknownWords(unknown, name, 1, 0) {
+			u.fileLine = n + 1
+			file.unknownWords = append(file.unknownWords, u)
+		}
+		file.shares = append(file.shares, parsedShare{number: number, lines: 1, words: len(tokens), data: decoded})
+	}
+	return file, nil
+}
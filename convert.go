@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// convert writes the shares of a shares file again with another
+// dictionary. Every share is decoded to its bytes with the old dictionary
+// and encoded with the new one, so the secret is never put back together
+// and any number of shares can be converted.
+func (g *gsssa) convert() {
+	parsed := g.readShares()
+	if len(parsed.shares) == 0 {
+		fmt.Fprintf(os.Stderr, "No shares found in \"%s\".\n", g.sharesFilename)
+		os.Exit(1)
+	}
+
+	g.dictionary, g.lang, g.stdinDictionary = g.toDictionary, g.toLang, nil
+	g.section = ""
+	enc, err := g.shareEncoding(parsed.encoding)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	set := &shareSet{
+		min:        parsed.min,
+		amount:     parsed.amount,
+		dictionary: g.dictionaryName(),
+		dictFP:     encodingFingerprint(enc),
+		encoding:   parsed.encoding,
+		checksum:   parsed.hasChecksum,
+		note:       parsed.note,
+		created:    parsed.created,
+		lang:       g.recordedLanguage(),
+		secretFP:   parsed.fingerprint,
+		lineCRC:    parsed.lineCRC,
+		threshold:  parsed.threshold,
+		perLine:    parsed.perLine,
+	}
+	if len(set.encoding) == 0 {
+		set.encoding = defaultEncoding
+	}
+
+	g.format = parsed.format
+	if len(g.toFormat) > 0 {
+		g.format = g.toFormat
+	}
+	// Only the text format has line checksums, and only it and JSON say
+	// that the shares start with the threshold.
+	if g.format != "text" {
+		set.lineCRC = false
+	}
+	if g.format != "text" && g.format != "json" {
+		set.threshold = false
+	}
+	if g.format == "text" && parsed.groupSize > 0 {
+		if err := checkGroupSeparator(parsed.groupSeparator, enc); err != nil {
+			fmt.Fprintf(os.Stderr, "WARNING: The words of the shares aren't grouped like in \"%s\": %v.\n", g.sharesFilename, err)
+		} else {
+			set.groupSize, set.groupSeparator = parsed.groupSize, parsed.groupSeparator
+		}
+	}
+	if parsed.comments {
+		fmt.Fprintf(os.Stderr, "WARNING: The comment lines of \"%s\" that gsssa doesn't write are left out.\n", g.sharesFilename)
+	}
+	for _, s := range parsed.shares {
+		data := s.data
+		if set.threshold {
+			data = append([]byte{byte(s.min), byte(s.amount)}, data...)
+		}
+		share := createdShare{number: s.number, holder: s.holder, label: s.label, data: data, lines: enc.encode(data)}
+		if set.perLine > 0 {
+			share.lines = rewrapLines(share.lines, set.perLine)
+		}
+		if set.lineCRC {
+			share.lines, err = addLineChecksums(share.lines, enc)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+		}
+		set.shares = append(set.shares, share)
+	}
+
+	content, err := g.render(set)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if g.convertOut == stdoutFile {
+		fmt.Print(content)
+		return
+	}
+	if _, err := os.Stat(g.convertOut); !os.IsNotExist(err) && !g.forceOverwrite {
+		fmt.Fprintf(os.Stderr, "The shares file \"%s\" already exists. To force overwriting, use --force flag.\n", g.convertOut)
+		os.Exit(1)
+	}
+	if err := g.writeShareFile(g.convertOut, content); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "%d shares converted to %s.\n", len(set.shares), g.convertOut)
+}
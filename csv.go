@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// csvColumns are the first cells of the header row of --format csv. The cells
+// after them hold what is known about the shares as key=value.
+var csvColumns = []string{"share_number", "line_number", "words"}
+
+// renderCSV renders the shares as CSV with one row per line of a share.
+func renderCSV(set *shareSet) (string, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	header := append([]string{}, csvColumns...)
+	header = append(header,
+		fmt.Sprintf("min=%d", set.min),
+		fmt.Sprintf("amount=%d", set.amount),
+		"dictionary="+set.dictionary,
+		"encoding="+set.encoding)
+	if len(set.lang) > 0 {
+		header = append(header, "lang="+set.lang)
+	}
+	if len(set.dictFP) > 0 {
+		header = append(header, "dictionary-fingerprint="+set.dictFP)
+	}
+	if set.checksum {
+		header = append(header, "checksum=sha256-4")
+	}
+	if err := w.Write(header); err != nil {
+		return "", err
+	}
+
+	for _, share := range set.shares {
+		for n, line := range share.lines {
+			row := []string{strconv.Itoa(share.number), strconv.Itoa(n + 1), strings.Join(line, " ")}
+			if err := w.Write(row); err != nil {
+				return "", err
+			}
+		}
+	}
+	w.Flush()
+	return buf.String(), w.Error()
+}
+
+// isCSVShares reports whether data looks like a file written with
+// --format csv.
+func isCSVShares(data string) bool {
+	return strings.HasPrefix(strings.TrimSpace(data), strings.Join(csvColumns, ","))
+}
+
+// parseCSVShares reads the shares from a file written with --format csv. The
+// rows of a share can be in any order.
+func (g *gsssa) parseCSVShares(data string) (*sharesFile, error) {
+	r := csv.NewReader(strings.NewReader(data))
+	r.FieldsPerRecord = -1
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("can't parse the CSV shares file: %v", err)
+	}
+	if len(rows) == 0 || len(rows[0]) < len(csvColumns) || strings.Join(rows[0][:len(csvColumns)], ",") != strings.Join(csvColumns, ",") {
+		return nil, fmt.Errorf("the CSV shares file should start with the header row %s", strings.Join(csvColumns, ","))
+	}
+
+	file := &sharesFile{}
+	encoding, fingerprint := defaultEncoding, ""
+	for _, cell := range rows[0][len(csvColumns):] {
+		kv := strings.SplitN(cell, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch kv[0] {
+		case "min":
+			file.min, _ = strconv.Atoi(kv[1])
+		case "amount":
+			file.amount, _ = strconv.Atoi(kv[1])
+		case "encoding":
+			encoding = kv[1]
+		case "dictionary-fingerprint":
+			fingerprint = kv[1]
+		case "lang":
+			g.useLanguage(kv[1])
+		case "checksum":
+			file.hasChecksum = true
+		}
+	}
+
+	file.encoding = encoding
+	enc, err := g.shareEncoding(encoding)
+	if err != nil {
+		return nil, err
+	}
+	if err := g.checkFingerprint(enc, fingerprint); err != nil {
+		return nil, err
+	}
+
+	type csvLine struct {
+		number int
+		row    int // of the CSV file, from 1 for the header row
+		words  []string
+	}
+	var order []int
+	lines := make(map[int][]csvLine)
+	for i, row := range rows[1:] {
+		if len(row) < len(csvColumns) {
+			return nil, fmt.Errorf("row %d of the CSV shares file has %d cells, not %d", i+2, len(row), len(csvColumns))
+		}
+		share, err1 := strconv.Atoi(row[0])
+		line, err2 := strconv.Atoi(row[1])
+		if err1 != nil || err2 != nil {
+			return nil, fmt.Errorf("row %d of the CSV shares file should start with the share and line numbers", i+2)
+		}
+		if _, ok := lines[share]; !ok {
+			order = append(order, share)
+		}
+		lines[share] = append(lines[share], csvLine{line, i + 2, strings.Fields(row[2])})
+	}
+
+	for _, number := range order {
+		share := parsedShare{number: number}
+		sort.SliceStable(lines[number], func(a, b int) bool {
+			return lines[number][a].number < lines[number][b].number
+		})
+		name := shareName(number, len(file.shares)+1)
+		malformed := false
+		for _, line := range lines[number] {
+			decoded, unknown, err := enc.decodeLine(line.words)
+			if err != nil {
+				file.malformed = append(file.malformed, fmt.Sprintf("row %d of the CSV shares file: %v", line.row, positionError(err, name, line.number, 0)))
+				malformed = true
+				break
+			}
+			file.unknownWords = append(file.unknownWords, placeUnknownWords(unknown, name, line.number, 0)...)
+			share.data = append(share.data, decoded...)
+			share.lines++
+			share.words += len(line.words)
+		}
+		if !malformed {
+			file.shares = append(file.shares, share)
+		}
+	}
+	return file, nil
+}
@@ -0,0 +1,646 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// dictionaryProblem is something wrong with a word list. Blocking problems
+// make the list unusable, the others make mistakes more likely.
+type dictionaryProblem struct {
+	blocking bool
+	message  string
+}
+
+// dictionaryLines splits a word list file into lines. Files saved on
+// Windows can start with a byte order mark and end their lines with "\r\n",
+// both are removed.
+func dictionaryLines(data string) []string {
+	lines := strings.Split(strings.TrimPrefix(data, "\ufeff"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	return lines
+}
+
+// numberedLine is a line of a numbered word list, like the EFF lists: a dice
+// or line index, tabs or spaces and the word.
+var numberedLine = regexp.MustCompile(`^\s*[0-9]+[\t ]+(\S+)\s*$`)
+
+// wordColumn returns the words of the lines of a word list in format: plain
+// with one word per line, numbered like numberedLine, or auto to find out
+// which one from the first lines that aren't empty. Empty lines are kept,
+// so problems can still be reported by line.
+func wordColumn(lines []string, format string) ([]string, error) {
+	if format == "auto" {
+		format, seen := "numbered", 0
+		for _, line := range lines {
+			if len(strings.TrimSpace(line)) == 0 {
+				continue
+			}
+			if !numberedLine.MatchString(line) {
+				format = "plain"
+			}
+			if seen++; seen == 5 {
+				break
+			}
+		}
+		if seen == 0 {
+			format = "plain"
+		}
+		return wordColumn(lines, format)
+	}
+	if format != "numbered" {
+		return lines, nil
+	}
+
+	words := make([]string, len(lines))
+	for i, line := range lines {
+		if len(strings.TrimSpace(line)) == 0 {
+			continue
+		}
+		m := numberedLine.FindStringSubmatch(line)
+		if m == nil {
+			return nil, fmt.Errorf("line %d \"%s\" is not a number followed by a word", i+1, line)
+		}
+		words[i] = m[1]
+	}
+	return words, nil
+}
+
+// normalizeWord puts a word into Unicode normalization form C, so a word
+// with accents matches whether its letters were stored composed or
+// decomposed. The words of dictionaries and of shares files both go through
+// it.
+func normalizeWord(word string) string {
+	return norm.NFC.String(word)
+}
+
+// wordKey is how a word is looked up in a dictionary: normalized, and in
+// lower case unless caseSensitive is set, so words copied in capitals are
+// found as well.
+func wordKey(word string, caseSensitive bool) string {
+	word = normalizeWord(word)
+	if !caseSensitive {
+		word = strings.ToLower(word)
+	}
+	return word
+}
+
+// wordIndex finds the position of words in a dictionary by their wordKey.
+// A word can also be given by a unique prefix of at least minPrefix
+// letters, unless minPrefix is 0.
+type wordIndex struct {
+	words         []string
+	keys          []string
+	positions     map[string]int
+	caseSensitive bool
+	minPrefix     int
+}
+
+// newWordIndex indexes the words. Words with the same key are an error:
+// without caseSensitive those are words that only differ in case.
+func newWordIndex(words []string, caseSensitive bool, minPrefix int) (*wordIndex, error) {
+	x := &wordIndex{words: words, positions: make(map[string]int), caseSensitive: caseSensitive, minPrefix: minPrefix}
+	for i, w := range words {
+		key := wordKey(w, caseSensitive)
+		if j, ok := x.positions[key]; ok {
+			return nil, fmt.Errorf("the dictionary words \"%s\" and \"%s\" only differ in case, use --case-sensitive", words[j], w)
+		}
+		x.positions[key] = i
+		x.keys = append(x.keys, key)
+	}
+	return x, nil
+}
+
+// find returns the position of word, and false if the dictionary doesn't
+// have it. A prefix that more than one word starts with is an error.
+func (x *wordIndex) find(word string) (int, bool, error) {
+	key := wordKey(word, x.caseSensitive)
+	if i, ok := x.positions[key]; ok {
+		return i, true, nil
+	}
+	if x.minPrefix == 0 || utf8.RuneCountInString(key) < x.minPrefix {
+		return 0, false, nil
+	}
+
+	var matches []int
+	for i, k := range x.keys {
+		if strings.HasPrefix(k, key) {
+			matches = append(matches, i)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return 0, false, nil
+	case 1:
+		return matches[0], true, nil
+	}
+	var candidates []string
+	for _, i := range matches {
+		candidates = append(candidates, x.words[i])
+	}
+	return 0, false, fmt.Errorf("\"%s\" is the start of more than one word: %s", word, strings.Join(candidates, ", "))
+}
+
+// suggest returns the dictionary words closest to word that isn't one of
+// them, at most 3, and their edit distance to it. Words more than 2 edits
+// away aren't suggested.
+func (x *wordIndex) suggest(word string) ([]string, int) {
+	key := []rune(wordKey(word, x.caseSensitive))
+	best := 3
+	var suggestions []string
+	for i, k := range x.keys {
+		d := editDistance(key, []rune(k))
+		if d < best {
+			best, suggestions = d, nil
+		}
+		if d == best {
+			suggestions = append(suggestions, x.words[i])
+		}
+	}
+	if len(suggestions) > 3 {
+		suggestions = suggestions[:3]
+	}
+	return suggestions, best
+}
+
+// editDistance is the number of letters that have to be changed, added,
+// removed or swapped with their neighbour to turn a into b.
+func editDistance(a, b []rune) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = min3(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] && d[i-2][j-2]+1 < d[i][j] {
+				d[i][j] = d[i-2][j-2] + 1
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+// minDictionaryWords is how many words a word list needs for the words
+// encoding, one for every byte value.
+const minDictionaryWords = 256
+
+// checkDictionary audits the lines of a word list, one word per line, that
+// needs at least minWords words. A newline at the end of the last line is
+// fine.
+func checkDictionary(lines []string, minWords int) []dictionaryProblem {
+	if len(lines) > 0 && len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+
+	var problems []dictionaryProblem
+	blocking := func(format string, a ...interface{}) {
+		problems = append(problems, dictionaryProblem{true, fmt.Sprintf(format, a...)})
+	}
+	warning := func(format string, a ...interface{}) {
+		problems = append(problems, dictionaryProblem{false, fmt.Sprintf(format, a...)})
+	}
+
+	seen := make(map[string]int)
+	var words []string
+	var nonASCII []string
+	for i, line := range lines {
+		word := normalizeWord(strings.TrimSpace(line))
+		switch {
+		case len(word) == 0:
+			warning("line %d is empty and is skipped", i+1)
+			continue
+		case strings.TrimSpace(line) != line:
+			warning("line %d has whitespace around \"%s\", which is ignored", i+1, word)
+		}
+		switch {
+		case strings.IndexFunc(word, unicode.IsSpace) >= 0:
+			blocking("line %d \"%s\" has a space in it, it would be read back as two words", i+1, word)
+		case strings.IndexFunc(word, unicode.IsControl) >= 0:
+			blocking("line %d %q has a control character in it", i+1, word)
+		case word[0] == '#':
+			blocking("line %d \"%s\" starts with \"#\", lines starting with it would be read back as comments", i+1, word)
+		case strings.Contains(word, "#"):
+			blocking("line %d \"%s\" has a \"#\" in it", i+1, word)
+		case word == defaultGroupSeparator:
+			blocking("line %d \"%s\" is the group separator, which reveal skips", i+1, word)
+		}
+		if first, ok := seen[word]; ok {
+			blocking("line %d \"%s\" is the same word as line %d", i+1, word, first)
+			continue
+		}
+		seen[word] = i + 1
+		words = append(words, word)
+
+		for _, r := range word {
+			if r > 127 {
+				nonASCII = append(nonASCII, fmt.Sprintf("line %d \"%s\"", i+1, word))
+				break
+			}
+		}
+	}
+
+	if len(words) < minWords {
+		blocking("it has only %d usable words, but needs at least %d", len(words), minWords)
+	}
+	if len(nonASCII) > 0 {
+		examples := nonASCII
+		if len(examples) > 5 {
+			examples = examples[:5]
+		}
+		warning("the words of %d lines have non-ASCII characters, which can be hard to type on other keyboards: %s", len(nonASCII), strings.Join(examples, ", "))
+	}
+	if shared := sharedPrefixes(words); len(shared) > 0 {
+		examples := shared
+		if len(examples) > 5 {
+			examples = examples[:5]
+		}
+		warning("%d words start with the same %d letters as another word, so they can't be told apart by their start: %s", len(shared), prefixLength, strings.Join(examples, ", "))
+	}
+	for _, pair := range confusablePairs(words) {
+		a, b := words[pair[0]], words[pair[1]]
+		warning("\"%s\" (line %d) and \"%s\" (line %d) differ in only one letter or two swapped letters and are easily mixed up", a, seen[a], b, seen[b])
+	}
+	return problems
+}
+
+// dictionaryWords returns the words of the lines of a word list, without
+// the whitespace around them and without empty lines, normalized.
+func dictionaryWords(lines []string) []string {
+	var words []string
+	for _, line := range lines {
+		if word := normalizeWord(strings.TrimSpace(line)); len(word) > 0 {
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+// sharedPrefixes describes the words that start with the same prefixLength
+// letters as an earlier word.
+func sharedPrefixes(words []string) []string {
+	var shared []string
+	first := make(map[string]string)
+	for _, w := range words {
+		prefix := w
+		if r := []rune(w); len(r) > prefixLength {
+			prefix = string(r[:prefixLength])
+		}
+		if f, ok := first[prefix]; ok {
+			shared = append(shared, fmt.Sprintf("\"%s\" and \"%s\"", f, w))
+			continue
+		}
+		first[prefix] = w
+	}
+	return shared
+}
+
+// confusablePairs returns the positions of the pairs of words that are
+// confusable.
+func confusablePairs(words []string) [][2]int {
+	runes := make([][]rune, len(words))
+	for i, w := range words {
+		runes[i] = []rune(w)
+	}
+
+	var pairs [][2]int
+	for i := range runes {
+		for j := i + 1; j < len(runes); j++ {
+			if confusable(runes[i], runes[j]) {
+				pairs = append(pairs, [2]int{i, j})
+			}
+		}
+	}
+	return pairs
+}
+
+// confusable reports whether a and b differ by exactly one changed, added
+// or removed letter, or by two neighbouring letters that are swapped, like
+// "quite" and "quiet".
+func confusable(a, b []rune) bool {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	if len(b)-len(a) > 1 {
+		return false
+	}
+
+	i := 0
+	for i < len(a) && a[i] == b[i] {
+		i++
+	}
+	if len(a) < len(b) {
+		return string(a[i:]) == string(b[i+1:])
+	}
+	if i == len(a) {
+		return false
+	}
+	if string(a[i+1:]) == string(b[i+1:]) {
+		return true
+	}
+	return i+1 < len(a) && a[i] == b[i+1] && a[i+1] == b[i] && string(a[i+2:]) == string(b[i+2:])
+}
+
+// encodingWords returns the dictionary words enc writes shares with, nil if
+// it doesn't use a dictionary.
+func encodingWords(enc shareEncoding) []string {
+	switch e := enc.(type) {
+	case *wordsEncoding:
+		return e.words[:256]
+	case *packedWordsEncoding:
+		return e.words
+	case *nibbleEncoding:
+		return e.words
+	}
+	return nil
+}
+
+// checkConfusable warns about the confusable words enc writes shares with
+// from a --dictionary, or exits with --strict-dictionary. The built-in lists
+// have some too, but their words are told apart by their start, so they are
+// only checked with --strict-dictionary.
+func (g *gsssa) checkConfusable(enc shareEncoding) {
+	if len(g.dictionary) == 0 && !g.strictDictionary {
+		return
+	}
+	words := encodingWords(enc)
+	var described []string
+	for _, pair := range confusablePairs(words) {
+		described = append(described, fmt.Sprintf("\"%s\" and \"%s\"", words[pair[0]], words[pair[1]]))
+	}
+	if len(described) == 0 {
+		return
+	}
+
+	examples := described
+	if len(examples) > 10 {
+		examples = append(examples[:10:10], "and more")
+	}
+	message := fmt.Sprintf("Dictionary words in use that differ in only one letter or two swapped letters, which holders can easily mix up (%d pairs): %s.", len(described), strings.Join(examples, ", "))
+	if g.strictDictionary {
+		fmt.Fprintf(os.Stderr, "%s No shares were created because of --strict-dictionary.\n", message)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "WARNING: %s\n", message)
+}
+
+// dictCheck prints the problems of the --dictionary word list, and exits
+// non-zero if any of them make it unusable.
+func (g *gsssa) dictCheck() {
+	lines, err := wordColumn(dictionaryLines(g.readDictionary()), g.dictionaryFormat)
+	if err != nil {
+		fmt.Printf("ERROR: %s\n\"%s\" can't be used as a dictionary.\n", err, g.dictionary)
+		os.Exit(1)
+	}
+	problems := checkDictionary(lines, minDictionaryWords)
+	failed := false
+	for _, p := range problems {
+		if p.blocking {
+			failed = true
+			fmt.Printf("ERROR: %s\n", p.message)
+		} else {
+			fmt.Printf("WARNING: %s\n", p.message)
+		}
+	}
+
+	switch {
+	case failed:
+		fmt.Printf("\"%s\" can't be used as a dictionary.\n", g.dictionary)
+		os.Exit(1)
+	case len(problems) > 0:
+		fmt.Printf("\"%s\" can be used as a dictionary, but has the warnings above.\n", g.dictionary)
+	default:
+		fmt.Printf("\"%s\" can be used as a dictionary.\n", g.dictionary)
+	}
+}
+
+// detectDictionary finds the built-in word list of shares that have words
+// the English one doesn't have, or couldn't be read with it, by reading
+// them with every other one. The first one that has all the words is used,
+// else the one that has the most of them, so its unknown words can be
+// reported. parsed and err are what reading them with the English one gave.
+func (g *gsssa) detectDictionary(data string, parsed *sharesFile, err error) (*sharesFile, error) {
+	type candidate struct {
+		lang   string
+		parsed *sharesFile
+	}
+	var candidates []candidate
+	if err == nil {
+		candidates = append(candidates, candidate{defaultLanguage, parsed})
+	}
+
+	for _, lang := range languageCodes() {
+		if lang == defaultLanguage {
+			continue
+		}
+		g.lang = lang
+		p, e := g.parseSharesData(data)
+		if e != nil {
+			continue
+		}
+		if len(p.unknownWords) == 0 && len(p.malformed) == 0 {
+			fmt.Fprintf(os.Stderr, "The shares are in the built-in word list \"%s\".\n", lang)
+			return p, nil
+		}
+		candidates = append(candidates, candidate{lang, p})
+	}
+	g.lang = ""
+
+	if len(candidates) == 0 {
+		return nil, err
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return len(candidates[i].parsed.unknownWords) < len(candidates[j].parsed.unknownWords)
+	})
+	var closest []string
+	for _, c := range candidates {
+		closest = append(closest, fmt.Sprintf("\"%s\" doesn't have %d of them", c.lang, len(c.parsed.unknownWords)))
+	}
+	fmt.Fprintf(os.Stderr, "None of the built-in word lists has all the words of the shares: %s. Some words may be copied wrong, or the shares were created with a --dictionary. The words are looked up in \"%s\".\n", strings.Join(closest, ", "), candidates[0].lang)
+	g.lang = candidates[0].lang
+	return candidates[0].parsed, nil
+}
+
+// jsonWordTable is the JSON output of the words command.
+type jsonWordTable struct {
+	Dictionary string   `json:"dictionary"`
+	Lang       string   `json:"lang,omitempty"`
+	Encoding   string   `json:"encoding"`
+	DictFP     string   `json:"dictionary_fingerprint"`
+	Words      []string `json:"words"`
+}
+
+// wordTable prints the words the words encoding uses with the dictionary,
+// by the value they stand for, and the fingerprint that goes into shares
+// files made with them.
+func (g *gsssa) wordTable() {
+	g.encoding = defaultEncoding
+	if g.dense {
+		if bits := denseWordBits(g.getWordsFromDictionary(minDictionaryWords)); bits > 8 {
+			g.encoding = fmt.Sprintf("%s%d", packedWordsPrefix, bits)
+		}
+	}
+	enc, err := g.shareEncoding(g.encoding)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	words := encodingWords(enc)
+	table := jsonWordTable{
+		Dictionary: g.dictionaryName(),
+		Lang:       g.recordedLanguage(),
+		Encoding:   g.encoding,
+		DictFP:     encodingFingerprint(enc),
+		Words:      words,
+	}
+
+	if g.format == "json" {
+		data, err := json.MarshalIndent(table, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%+v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(data))
+		return
+	}
+
+	fmt.Printf("# dictionary=%s", table.Dictionary)
+	if len(table.Lang) > 0 {
+		fmt.Printf(" lang=%s", table.Lang)
+	}
+	fmt.Printf(" encoding=%s dictionary-fingerprint=%s words=%d\n", table.Encoding, table.DictFP, len(words))
+	for i, w := range words {
+		fmt.Printf("%d\t%s\n", i, w)
+	}
+}
+
+// generateDictionary picks the count most frequent words of corpus that are
+// minLen to maxLen letters long. Words are only letters, in lower case, and
+// the ones that are confusable with a more frequent word, or start with
+// the same prefixLength letters, are left out, so the list passes dict check.
+func generateDictionary(corpus string, count, minLen, maxLen int) ([]string, error) {
+	frequency := make(map[string]int)
+	for _, w := range strings.FieldsFunc(normalizeWord(corpus), func(r rune) bool { return !unicode.IsLetter(r) }) {
+		w = strings.ToLower(w)
+		if n := utf8.RuneCountInString(w); n >= minLen && n <= maxLen {
+			frequency[w]++
+		}
+	}
+
+	var candidates []string
+	for w := range frequency {
+		candidates = append(candidates, w)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if frequency[a] != frequency[b] {
+			return frequency[a] > frequency[b]
+		}
+		return a < b
+	})
+
+	var words []string
+	prefixes := make(map[string]bool)
+	for _, w := range candidates {
+		if len(words) == count {
+			break
+		}
+		prefix := w
+		if r := []rune(w); len(r) > prefixLength {
+			prefix = string(r[:prefixLength])
+		}
+		near := prefixes[prefix]
+		for i := 0; !near && i < len(words); i++ {
+			near = confusable([]rune(w), []rune(words[i]))
+		}
+		if !near {
+			words = append(words, w)
+			prefixes[prefix] = true
+		}
+	}
+	if len(words) < count {
+		return nil, fmt.Errorf("the corpus has only %d usable words of %d to %d letters, but %d are needed", len(words), minLen, maxLen, count)
+	}
+	return words, nil
+}
+
+// dictGenerate writes a word list made from the --input corpus.
+func (g *gsssa) dictGenerate() {
+	if g.genCount < minDictionaryWords || g.genMinLen < 1 || g.genMaxLen < g.genMinLen {
+		fmt.Fprintf(os.Stderr, "--count must be at least %d, and --min-len at least 1 and not more than --max-len.\n", minDictionaryWords)
+		os.Exit(1)
+	}
+
+	corpus, err := ioutil.ReadFile(g.genInput)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%+v\n", err)
+		os.Exit(1)
+	}
+	words, err := generateDictionary(string(corpus), g.genCount, g.genMinLen, g.genMaxLen)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	list := strings.Join(words, "\n") + "\n"
+	for _, p := range checkDictionary(dictionaryLines(list), minDictionaryWords) {
+		if p.blocking {
+			fmt.Fprintf(os.Stderr, "The generated word list can't be used as a dictionary: %s.\n", p.message)
+			os.Exit(1)
+		}
+	}
+
+	if len(g.genOut) == 0 || g.genOut == "-" {
+		fmt.Print(list)
+		return
+	}
+	flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if g.forceOverwrite {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	f, err := os.OpenFile(g.genOut, flags, 0644)
+	if os.IsExist(err) {
+		fmt.Fprintf(os.Stderr, "The file \"%s\" already exists. To force overwriting, use --force flag.\n", g.genOut)
+		os.Exit(1)
+	}
+	if err == nil {
+		_, err = f.WriteString(list)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%+v\n", err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "%d words written to %s.\n", len(words), g.genOut)
+}
@@ -0,0 +1,414 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Whitespace after the words of a dictionary, like trailing spaces, tabs
+// and "\r", isn't part of the words.
+func TestDictionaryTrailingSpaces(t *testing.T) {
+	spaced := "testdata/dictionary-trailing-spaces.txt"
+	data := readTestdata(t, "dictionary-trailing-spaces-shares.txt")
+	for _, dictionary := range []string{spaced, "wordlists/english.txt"} {
+		g := testGsssa()
+		g.dictionary = dictionary
+		if secret := revealData(t, g, data); string(secret) != "spaces after the words" {
+			t.Errorf("with %s: revealed %q", dictionary, secret)
+		}
+	}
+
+	// Shares written with it are read with the same dictionary without the
+	// whitespace.
+	g := testGsssa()
+	g.dictionary = spaced
+	enc, err := g.shareEncoding(defaultEncoding)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.dictionary = "wordlists/english.txt"
+	clean, err := g.shareEncoding(defaultEncoding)
+	if err != nil {
+		t.Fatal(err)
+	}
+	share := make([]byte, 256)
+	for i := range share {
+		share[i] = byte(i)
+	}
+	var decoded []byte
+	for _, line := range enc.encode(share) {
+		d, _, err := clean.decodeLine(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded = append(decoded, d...)
+	}
+	if !bytes.Equal(decoded, share) {
+		t.Errorf("decoded %x, want %x", decoded, share)
+	}
+	if encodingFingerprint(enc) != encodingFingerprint(clean) {
+		t.Errorf("the dictionary fingerprint is %s with the whitespace and %s without it", encodingFingerprint(enc), encodingFingerprint(clean))
+	}
+}
+
+// The built-in word lists are checked here instead of every time they are
+// used: every one has 256 words that can be told apart by their first
+// prefixLength letters. Their warnings, like about letters with accents, are
+// how the words of the languages are.
+func TestBuiltInWordLists(t *testing.T) {
+	for _, lang := range languageCodes() {
+		list, err := embeddedWords(lang)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines := dictionaryLines(list)
+		if words := dictionaryWords(lines); len(words) != minDictionaryWords {
+			t.Errorf("%s: %d words, want %d", lang, len(words), minDictionaryWords)
+		}
+		for _, p := range checkDictionary(lines, minDictionaryWords) {
+			if p.blocking {
+				t.Errorf("%s: %s", lang, p.message)
+			}
+		}
+		for _, shared := range sharedPrefixes(dictionaryWords(lines)) {
+			t.Errorf("%s: %s start the same", lang, shared)
+		}
+	}
+}
+
+// swappedDictionary writes the English word list with its first two words
+// swapped, a dictionary that reads the same words as other bytes.
+func swappedDictionary(t *testing.T) string {
+	t.Helper()
+	list, err := embeddedWords(defaultLanguage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	words := dictionaryWords(dictionaryLines(list))
+	words[0], words[1] = words[1], words[0]
+	return writeTestFile(t, "swapped.txt", strings.Join(words, "\n")+"\n")
+}
+
+// A shares file is only read with the dictionary of its fingerprint, or any
+// dictionary with --ignore-dictionary-mismatch or with a file without one.
+func TestDictionaryFingerprint(t *testing.T) {
+	swapped := swappedDictionary(t)
+	data := readTestdata(t, "text.golden")
+	legacy := strings.Replace(data, " dictionary-fingerprint=ac19ed86", "", 1)
+	tests := []struct {
+		name, data, dictionary string
+		ignore, mismatch       bool
+	}{
+		{"the same dictionary", data, "", false, false},
+		{"another dictionary", data, swapped, false, true},
+		{"--ignore-dictionary-mismatch", data, swapped, true, false},
+		{"no fingerprint", legacy, swapped, false, false},
+	}
+	for _, test := range tests {
+		g := testGsssa()
+		g.dictionary, g.ignoreDictMismatch = test.dictionary, test.ignore
+		_, err := g.parseSharesData(test.data)
+		if mismatch := err != nil && strings.Contains(err.Error(), "created with another dictionary (fingerprint ac19ed86)"); mismatch != test.mismatch || (err != nil && !mismatch) {
+			t.Errorf("%s: got %v, want a mismatch %v", test.name, err, test.mismatch)
+		}
+	}
+
+	// reveal exits with it before anything is revealed.
+	dir := t.TempDir()
+	mustRunGsssa(t, dir, "the right words", "create", "--secret-stdin", "--no-print")
+	run := runGsssa(t, dir, "", "reveal", "--dictionary", swapped)
+	if run.ok || !strings.Contains(run.stderr, "--ignore-dictionary-mismatch") || strings.Contains(run.stdout, "the right words") {
+		t.Errorf("reveal with another dictionary: ok %v, want the mismatch:\n%s%s", run.ok, run.stdout, run.stderr)
+	}
+	if revealed := roundTrip(t, "the right words", nil, nil); revealed != "the right words" {
+		t.Errorf("with the same dictionary: revealed %q", revealed)
+	}
+}
+
+// Empty lines of a dictionary aren't words: they don't move the words after
+// them to other bytes, and don't count as words.
+func TestDictionaryBlankLines(t *testing.T) {
+	list, err := embeddedWords(defaultLanguage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	words := dictionaryWords(dictionaryLines(list))
+	var blank []string
+	for i, w := range words {
+		if i%10 == 3 {
+			blank = append(blank, "", "  ")
+		}
+		blank = append(blank, w)
+	}
+
+	g := testGsssa()
+	clean, err := g.shareEncoding(defaultEncoding)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.dictionary = writeTestFile(t, "blank.txt", strings.Join(blank, "\n")+"\n\n")
+	enc, err := g.shareEncoding(defaultEncoding)
+	if err != nil {
+		t.Fatal(err)
+	}
+	share := make([]byte, 256)
+	for i := range share {
+		share[i] = byte(i)
+	}
+	if a, b := fmt.Sprint(enc.encode(share)), fmt.Sprint(clean.encode(share)); a != b {
+		t.Errorf("with the empty lines the bytes 0 to 255 are %s, want %s", a, b)
+	}
+	if encodingFingerprint(enc) != encodingFingerprint(clean) {
+		t.Errorf("the fingerprint is %s with the empty lines and %s without them", encodingFingerprint(enc), encodingFingerprint(clean))
+	}
+
+	tests := []struct {
+		name   string
+		data   string
+		usable int
+	}{
+		{"256 words", strings.Join(words, "\n"), 256},
+		{"256 words and a newline", strings.Join(words, "\n") + "\n", 256},
+		{"255 words and a newline", strings.Join(words[:255], "\n") + "\n", 255},
+		{"255 words and an empty line", strings.Join(words[:100], "\n") + "\n\n" + strings.Join(words[100:255], "\n"), 255},
+	}
+	for _, test := range tests {
+		var blocking []string
+		for _, p := range checkDictionary(dictionaryLines(test.data), minDictionaryWords) {
+			if p.blocking {
+				blocking = append(blocking, p.message)
+			}
+		}
+		want := 0
+		if test.usable < minDictionaryWords {
+			want = 1
+		}
+		if len(blocking) != want || (want == 1 && !strings.Contains(blocking[0], "it has only 255 usable words, but needs at least 256")) {
+			t.Errorf("%s: problems %v", test.name, blocking)
+		}
+		if n := len(dictionaryWords(dictionaryLines(test.data))); n != test.usable {
+			t.Errorf("%s: %d words, want %d", test.name, n, test.usable)
+		}
+	}
+}
+
+// A dictionary saved on Windows, with a byte order mark and "\r\n", creates
+// shares that reveal with it and with the same words saved elsewhere.
+func TestDictionaryWindowsLineEndings(t *testing.T) {
+	crlf, err := filepath.Abs("testdata/dictionary-crlf.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lf, err := filepath.Abs("wordlists/english.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		create, reveal string
+	}{
+		{crlf, crlf},
+		{crlf, lf},
+		{lf, crlf},
+	}
+	for _, test := range tests {
+		revealed := roundTrip(t, "saved with notepad", []string{"--dictionary", test.create}, []string{"--dictionary", test.reveal})
+		if revealed != "saved with notepad" {
+			t.Errorf("created with %s, revealed with %s: %q", filepath.Base(test.create), filepath.Base(test.reveal), revealed)
+		}
+	}
+
+	g := testGsssa()
+	g.dictionary = crlf
+	for _, w := range g.getWordsFromDictionary(minDictionaryWords) {
+		if strings.ContainsAny(w, "\r\ufeff") {
+			t.Fatalf("the word %q has the line ending or the byte order mark", w)
+		}
+	}
+}
+
+// Words with accents are the same word whether their letters are composed
+// (NFC) or decomposed (NFD), in the dictionary and in the shares, and create
+// writes them composed.
+func TestDictionaryNormalization(t *testing.T) {
+	share := make([]byte, 256)
+	for i := range share {
+		share[i] = byte(i)
+	}
+	g := testGsssa()
+	g.lang = "fr"
+	composed, err := g.shareEncoding(defaultEncoding)
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, err := embeddedWords("fr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.dictionary = writeTestFile(t, "french-nfd.txt", norm.NFD.String(list))
+	decomposed, err := g.shareEncoding(defaultEncoding)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if encodingFingerprint(composed) != encodingFingerprint(decomposed) {
+		t.Errorf("the fingerprint is %s composed and %s decomposed", encodingFingerprint(composed), encodingFingerprint(decomposed))
+	}
+
+	accents := 0
+	for _, enc := range []shareEncoding{composed, decomposed} {
+		for _, line := range enc.encode(share) {
+			var nfd []string
+			for _, w := range line {
+				if !norm.NFC.IsNormalString(w) {
+					t.Errorf("\"%s\" isn't written composed", w)
+				}
+				if d := norm.NFD.String(w); d != w {
+					accents++
+					w = d
+				}
+				nfd = append(nfd, w)
+			}
+			for _, words := range [][]string{line, nfd} {
+				data, unknown, err := enc.decodeLine(words)
+				if err != nil || len(unknown) > 0 {
+					t.Fatalf("%v: unknown words %v, error %v", words, unknown, err)
+				}
+				if want, _, _ := composed.decodeLine(line); !bytes.Equal(data, want) {
+					t.Errorf("%v: decoded %x, want %x", words, data, want)
+				}
+			}
+		}
+	}
+	if accents == 0 {
+		t.Fatal("no words with accents")
+	}
+}
+
+// A word can be given by its start when only one word starts with it, and
+// it has at least minPrefix letters. A whole word is always that word.
+func TestWordIndexPrefixes(t *testing.T) {
+	words := []string{"apple", "applause", "banana", "band", "bandana"}
+	tests := []struct {
+		word      string
+		minPrefix int
+		index     int
+		found     bool
+		ambiguous bool
+	}{
+		{"apple", 3, 0, true, false},
+		{"appla", 3, 1, true, false},
+		{"APPLA", 3, 1, true, false},
+		{"band", 3, 3, true, false},
+		{"banda", 3, 4, true, false},
+		{"bana", 3, 2, true, false},
+		{"app", 3, 0, false, true},
+		{"appl", 3, 0, false, true},
+		{"ban", 3, 0, false, true},
+		{"ba", 3, 0, false, false},
+		{"bandanas", 3, 0, false, false},
+		{"cherry", 3, 0, false, false},
+		{"appla", 0, 0, false, false},
+		{"bana", 5, 0, false, false},
+	}
+	for _, test := range tests {
+		x, err := newWordIndex(words, false, test.minPrefix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		index, found, err := x.find(test.word)
+		if (err != nil) != test.ambiguous || found != test.found || (found && index != test.index) {
+			t.Errorf("%q with --min-prefix %d: found %v at %d, error %v", test.word, test.minPrefix, found, index, err)
+		}
+		if err != nil && !strings.Contains(err.Error(), "is the start of more than one word") {
+			t.Errorf("%q: got %v", test.word, err)
+		}
+	}
+}
+
+// --dictionary - reads the dictionary from stdin for create and for reveal,
+// but not when stdin has the secret or the shares.
+func TestDictionaryStdin(t *testing.T) {
+	swapped, err := ioutil.ReadFile(swappedDictionary(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := writeTestFile(t, "secret.txt", "piped words")
+	dir := t.TempDir()
+	mustRunGsssa(t, dir, string(swapped), "create", "--dictionary", "-", "--secret-file", secret, "--no-print")
+	if run := mustRunGsssa(t, dir, string(swapped), "reveal", "--dictionary", "-", "--raw"); run.stdout != "piped words" {
+		t.Errorf("revealed %q", run.stdout)
+	}
+	if run := runGsssa(t, dir, "", "reveal", "--raw"); run.ok || !strings.Contains(run.stderr, "created with another dictionary") {
+		t.Errorf("reveal without the piped dictionary: ok %v:\n%s", run.ok, run.stderr)
+	}
+
+	tests := []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"create", "--dictionary", "-", "--secret-stdin", "--no-print", "-f", "other.txt"}, "The dictionary can't be read from stdin when --secret-stdin reads the secret from it too."},
+		{[]string{"reveal", "--dictionary", "-", "-f", "-"}, "The shares and the dictionary can't both be read from stdin."},
+	}
+	for _, test := range tests {
+		if run := runGsssa(t, dir, string(swapped), test.args...); run.ok || !strings.Contains(run.stderr, test.err) {
+			t.Errorf("%v: ok %v, stderr %q", test.args, run.ok, run.stderr)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "other.txt")); err == nil {
+		t.Errorf("create wrote the shares file")
+	}
+}
+
+// Numbered word lists like the EFF long and short lists are read by their
+// word column, found out from their first lines or with --dictionary-format.
+func TestNumberedDictionaries(t *testing.T) {
+	list, err := embeddedWords(defaultLanguage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Join(dictionaryWords(dictionaryLines(list)), " ")
+	long, short := readTestdata(t, "dictionary-eff-long.txt"), readTestdata(t, "dictionary-eff-short.txt")
+	tests := []struct {
+		name, data, format string
+		err                bool
+	}{
+		{"the long list", long, "auto", false},
+		{"the short list", short, "auto", false},
+		{"the long list", long, "numbered", false},
+		{"a plain list", list, "auto", false},
+		{"a plain list", list, "plain", false},
+		{"a plain list", list, "numbered", true},
+	}
+	for _, test := range tests {
+		lines, err := wordColumn(dictionaryLines(test.data), test.format)
+		if (err != nil) != test.err {
+			t.Errorf("%s as %s: got %v", test.name, test.format, err)
+			continue
+		}
+		if err == nil && strings.Join(dictionaryWords(lines), " ") != want {
+			t.Errorf("%s as %s: other words than the English list", test.name, test.format)
+		}
+	}
+
+	// As plain lists their lines have spaces in them.
+	lines, _ := wordColumn(dictionaryLines(long), "plain")
+	if problems := checkDictionary(lines, minDictionaryWords); len(problems) == 0 || !problems[0].blocking || !strings.Contains(problems[0].message, "has a space in it") {
+		t.Errorf("the long list as plain: problems %v", problems)
+	}
+
+	for _, name := range []string{"dictionary-eff-long.txt", "dictionary-eff-short.txt"} {
+		path, err := filepath.Abs(filepath.Join("testdata", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if revealed := roundTrip(t, "eff words", []string{"--dictionary", path}, []string{"--dictionary", path}); revealed != "eff words" {
+			t.Errorf("%s: revealed %q", name, revealed)
+		}
+	}
+}
@@ -0,0 +1,913 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash/crc32"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// shareEncoding turns the bytes of a share into the text written to a shares
+// file and back. Every line is decoded on its own.
+type shareEncoding interface {
+	// encode turns the bytes of a share into lines of tokens.
+	encode(data []byte) [][]string
+	// decodeLine turns the tokens of one line back into bytes. Words that
+	// aren't in the dictionary are returned too if the encoding reads them
+	// anyway, with their index on the line.
+	decodeLine(tokens []string) ([]byte, []unknownWord, error)
+}
+
+// defaultEncoding is the encoding of files that don't name one, like the
+// files from before the header line.
+const defaultEncoding = "words"
+
+// shareEncoding returns the encoding with the given name. Only the words
+// encoding needs the dictionary.
+func (g *gsssa) shareEncoding(name string) (shareEncoding, error) {
+	switch name {
+	case "words", "":
+		e, err := newWordsEncoding(g.getWordsFromDictionary(minDictionaryWords), g.caseSensitive, g.minPrefix)
+		if err != nil {
+			return nil, err
+		}
+		e.autoCorrect = g.autoCorrect
+		return e, nil
+	case "raw":
+		return rawEncoding{}, nil
+	case "hex":
+		return hexEncoding{}, nil
+	case "base58":
+		return base58Encoding{}, nil
+	case "decimal":
+		return decimalEncoding{}, nil
+	case "nato":
+		return natoEncoding{}, nil
+	case "dice":
+		return g.diceEncoding()
+	case "bip39":
+		return g.bip39Encoding()
+	case "nibble":
+		return newNibbleEncoding(g.getWordsFromDictionary(nibbleWords), g.caseSensitive, g.minPrefix)
+	}
+	if strings.HasPrefix(name, packedWordsPrefix) {
+		bits, err := strconv.Atoi(strings.TrimPrefix(name, packedWordsPrefix))
+		if err == nil && bits > 8 && bits <= maxWordBits {
+			return newPackedWordsEncoding(g.getWordsFromDictionary(minDictionaryWords), bits, g.caseSensitive, g.minPrefix)
+		}
+	}
+	return nil, fmt.Errorf("unknown share encoding \"%s\"", name)
+}
+
+// checkGroupSeparator makes sure separator can't be mistaken for a part of
+// a share encoded with enc.
+func checkGroupSeparator(separator string, enc shareEncoding) error {
+	if len(separator) == 0 || strings.ContainsAny(separator, " \t#") {
+		return fmt.Errorf("the group separator \"%s\" can't be empty or have spaces or \"#\" in it", separator)
+	}
+
+	collides := false
+	if words, ok := enc.(*wordsEncoding); ok {
+		_, collides = words.index.positions[wordKey(separator, words.index.caseSensitive)]
+	} else if words, ok := enc.(*packedWordsEncoding); ok {
+		_, collides = words.index.positions[wordKey(separator, words.index.caseSensitive)]
+	} else if words, ok := enc.(*nibbleEncoding); ok {
+		_, collides = words.index.positions[wordKey(separator, words.index.caseSensitive)]
+	} else if _, _, err := enc.decodeLine([]string{separator}); err == nil {
+		collides = true
+	}
+	if collides {
+		return fmt.Errorf("the group separator \"%s\" is also a part of the share encoding, use another one", separator)
+	}
+	return nil
+}
+
+// lineChecksum is the checksum of the bytes of a share line with
+// --line-checksums.
+func lineChecksum(data []byte) byte {
+	return byte(crc32.ChecksumIEEE(data))
+}
+
+// checksumWords is how many tokens the line checksum takes in enc, like
+// the two words of a byte of the nibble encoding.
+func checksumWords(enc shareEncoding) int {
+	return len(enc.encode([]byte{0})[0])
+}
+
+// addLineChecksums appends the checksum of every line to it, encoded like the
+// rest of the line.
+func addLineChecksums(lines [][]string, enc shareEncoding) ([][]string, error) {
+	var checked [][]string
+	for _, line := range lines {
+		data, _, err := enc.decodeLine(line)
+		if err != nil {
+			return nil, err
+		}
+		sum := enc.encode([]byte{lineChecksum(data)})
+		checked = append(checked, append(append([]string{}, line...), sum[0]...))
+	}
+	return checked, nil
+}
+
+// unknownWord is a word of a share that isn't in the dictionary.
+type unknownWord struct {
+	word  string
+	share string // the name of the share it is in
+	line  int
+	index int // of the word on the line, from 1
+
+	fileLine    int      // the line of the file it is on, 0 if not known
+	suggestions []string // the closest dictionary words
+	correction  string   // the word it was read as with --auto-correct
+}
+
+func (u unknownWord) String() string {
+	s := fmt.Sprintf("%s: \"%s\"", u.position(), u.word)
+	if len(u.suggestions) > 0 {
+		s += ", did you mean: " + strings.Join(u.suggestions, ", ") + "?"
+	}
+	return s
+}
+
+// position says where the word is, like positionError.
+func (u unknownWord) position() string {
+	at := fmt.Sprintf("%s, line %d, word %d", u.share, u.line, u.index)
+	if u.fileLine > 0 {
+		return fmt.Sprintf("line %d (%s)", u.fileLine, at)
+	}
+	return at
+}
+
+// wordError is a problem with one of the tokens given to decodeLine, the
+// index-th one from 1.
+type wordError struct {
+	index int
+	err   error
+}
+
+func (e *wordError) Error() string {
+	return e.err.Error()
+}
+
+// placeUnknownWords sets the unknown words decodeLine returned as found on
+// line of share. offset is added to their index on the line.
+func placeUnknownWords(unknown []unknownWord, share string, line, offset int) []unknownWord {
+	for i := range unknown {
+		unknown[i].share, unknown[i].line = share, line
+		unknown[i].index += offset
+	}
+	return unknown
+}
+
+// resolveWord returns the dictionary word of enc that word is read as, like
+// the whole word for its start, or word itself if it isn't one.
+func resolveWord(enc shareEncoding, word string) string {
+	var index *wordIndex
+	switch e := enc.(type) {
+	case *wordsEncoding:
+		index = e.index
+	case *packedWordsEncoding:
+		index = e.index
+	case *nibbleEncoding:
+		index = e.index
+	default:
+		return word
+	}
+	if i, ok, err := index.find(word); ok && err == nil {
+		return index.words[i]
+	}
+	return word
+}
+
+// fingerprinter is a share encoding that depends on a dictionary. Its
+// fingerprint is written into shares files, so revealing with another
+// dictionary is noticed instead of giving a wrong secret.
+type fingerprinter interface {
+	fingerprint() string
+}
+
+// wordsFingerprint is the start of the SHA-256 of the words, one per line.
+func wordsFingerprint(words []string) string {
+	sum := sha256.Sum256([]byte(strings.Join(words, "\n")))
+	return hex.EncodeToString(sum[:4])
+}
+
+// encodingFingerprint returns the dictionary fingerprint of enc, empty if it
+// doesn't use a dictionary.
+func encodingFingerprint(enc shareEncoding) string {
+	if f, ok := enc.(fingerprinter); ok {
+		return f.fingerprint()
+	}
+	return ""
+}
+
+// checkFingerprint makes sure the shares are revealed with the dictionary
+// they were created with, when the file says which one that was.
+func (g *gsssa) checkFingerprint(enc shareEncoding, fingerprint string) error {
+	current := encodingFingerprint(enc)
+	if len(fingerprint) == 0 || len(current) == 0 || fingerprint == current || g.ignoreDictMismatch {
+		return nil
+	}
+	return fmt.Errorf("the shares were created with another dictionary (fingerprint %s) than the one used now (fingerprint %s). Reveal with the dictionary they were created with, or use --ignore-dictionary-mismatch", fingerprint, current)
+}
+
+// shareBytes decodes a share from sssa.Create. Every 44 characters of it are
+// 32 bytes.
+func shareBytes(share string) ([]byte, error) {
+	if len(share)%44 != 0 {
+		return nil, fmt.Errorf("a share should be a multiple of 44 characters long, but this one has %d", len(share))
+	}
+
+	var data []byte
+	for j := 0; j < len(share)/44; j++ {
+		part, err := base64.URLEncoding.DecodeString(share[j*44 : (j+1)*44])
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, part...)
+	}
+	return data, nil
+}
+
+// bytesToShare is the opposite of shareBytes, it returns the share as sssa
+// expects it.
+func bytesToShare(data []byte) string {
+	share := ""
+	for len(data) > 0 {
+		n := 32
+		if n > len(data) {
+			n = len(data)
+		}
+		share += base64.URLEncoding.EncodeToString(data[:n])
+		data = data[n:]
+	}
+	return share
+}
+
+// rawShareChunk is how long the base64 of each 32 bytes of a share is in the
+// shares of sssa, with its padding.
+const rawShareChunk = 44
+
+// rawShare returns the bytes of a share as sssa writes it, in base64, and
+// whether s is one. Those are pairs of base64 chunks, like bytesToShare
+// writes.
+func rawShare(s string) ([]byte, bool) {
+	if len(s) == 0 || len(s)%(2*rawShareChunk) != 0 {
+		return nil, false
+	}
+	var data []byte
+	for i := 0; i < len(s); i += rawShareChunk {
+		chunk, err := base64.URLEncoding.DecodeString(s[i : i+rawShareChunk])
+		if err != nil || len(chunk) != 32 {
+			return nil, false
+		}
+		data = append(data, chunk...)
+	}
+	return data, true
+}
+
+// wordsEncoding writes every byte as a dictionary word, 32 words per line.
+// Only the first 256 words of the dictionary are used.
+type wordsEncoding struct {
+	words []string
+	index *wordIndex
+
+	// autoCorrect reads an unknown word as the only dictionary word one
+	// edit away from it, if there is one.
+	autoCorrect bool
+}
+
+func newWordsEncoding(words []string, caseSensitive bool, minPrefix int) (*wordsEncoding, error) {
+	index, err := newWordIndex(words[:256], caseSensitive, minPrefix)
+	if err != nil {
+		return nil, err
+	}
+	return &wordsEncoding{words: words, index: index}, nil
+}
+
+func (e *wordsEncoding) fingerprint() string {
+	return wordsFingerprint(e.words[:256])
+}
+
+func (e *wordsEncoding) encode(data []byte) [][]string {
+	var lines [][]string
+	for len(data) > 0 {
+		n := 32
+		if n > len(data) {
+			n = len(data)
+		}
+
+		var line []string
+		for _, b := range data[:n] {
+			line = append(line, e.words[b])
+		}
+		lines = append(lines, line)
+		data = data[n:]
+	}
+	return lines
+}
+
+func (e *wordsEncoding) decodeLine(tokens []string) ([]byte, []unknownWord, error) {
+	var data []byte
+	var unknown []unknownWord
+	for n, w := range tokens {
+		i, ok, err := e.index.find(w)
+		if err != nil {
+			return nil, nil, &wordError{n + 1, err}
+		}
+		if !ok {
+			u := unknownWord{word: w, index: n + 1}
+			var distance int
+			u.suggestions, distance = e.index.suggest(w)
+			if e.autoCorrect && distance == 1 && len(u.suggestions) == 1 {
+				u.correction = u.suggestions[0]
+				i = e.index.positions[wordKey(u.correction, e.index.caseSensitive)]
+			}
+			unknown = append(unknown, u)
+		}
+		data = append(data, byte(i))
+	}
+	return data, unknown, nil
+}
+
+// nibbleWords is how many words the nibble encoding uses, one for every
+// half byte value.
+const nibbleWords = 16
+
+// nibbleEncoding writes every byte as two dictionary words, for its high and
+// its low four bits, 32 bytes per line. Only the first 16 words of the
+// dictionary are used, so a small list of words that are easy to tell apart
+// is enough.
+type nibbleEncoding struct {
+	words []string
+	index *wordIndex
+}
+
+func newNibbleEncoding(words []string, caseSensitive bool, minPrefix int) (*nibbleEncoding, error) {
+	index, err := newWordIndex(words[:nibbleWords], caseSensitive, minPrefix)
+	if err != nil {
+		return nil, err
+	}
+	return &nibbleEncoding{words: words[:nibbleWords], index: index}, nil
+}
+
+func (e *nibbleEncoding) fingerprint() string {
+	return wordsFingerprint(e.words)
+}
+
+func (e *nibbleEncoding) encode(data []byte) [][]string {
+	var lines [][]string
+	for len(data) > 0 {
+		n := 32
+		if n > len(data) {
+			n = len(data)
+		}
+
+		var line []string
+		for _, b := range data[:n] {
+			line = append(line, e.words[b>>4], e.words[b&0xf])
+		}
+		lines = append(lines, line)
+		data = data[n:]
+	}
+	return lines
+}
+
+func (e *nibbleEncoding) decodeLine(tokens []string) ([]byte, []unknownWord, error) {
+	if len(tokens)%2 != 0 {
+		return nil, nil, fmt.Errorf("a line of the nibble encoding has %d words, but every byte is two words", len(tokens))
+	}
+
+	var data []byte
+	for i := 0; i < len(tokens); i += 2 {
+		var b byte
+		for j, w := range tokens[i : i+2] {
+			v, ok, err := e.index.find(w)
+			if err != nil {
+				return nil, nil, &wordError{i + j + 1, err}
+			}
+			if !ok {
+				return nil, nil, &wordError{i + j + 1, fmt.Errorf("\"%s\" is not one of the first %d words of the dictionary", w, nibbleWords)}
+			}
+			b = b<<4 | byte(v)
+		}
+		data = append(data, b)
+	}
+	return data, nil, nil
+}
+
+// With --dense a dictionary of more than 256 words is used for symbols of
+// more than 8 bits. The encoding is then called packedWordsPrefix followed
+// by the number of bits, like "words-10" for 1024 words.
+const (
+	packedWordsPrefix = "words-"
+	maxWordBits       = 16
+)
+
+// denseWordBits is how many bits a word can stand for with the dictionary,
+// the largest power of two of words it has.
+func denseWordBits(words []string) int {
+	bits := 8
+	for bits < maxWordBits && 1<<uint(bits+1) <= len(words) {
+		bits++
+	}
+	return bits
+}
+
+// packedWordsEncoding writes the bytes of every line as words that stand for
+// bits bits each, 32 bytes per line. The bits of a line are followed by a 1
+// bit and then 0 bits up to the end of the last word, so the number of bytes
+// is known whatever the line length.
+type packedWordsEncoding struct {
+	bits  int
+	words []string
+	index *wordIndex
+}
+
+func newPackedWordsEncoding(words []string, bits int, caseSensitive bool, minPrefix int) (*packedWordsEncoding, error) {
+	n := 1 << uint(bits)
+	if len(words) < n {
+		return nil, fmt.Errorf("the shares need a dictionary of at least %d words, but it only has %d", n, len(words))
+	}
+
+	index, err := newWordIndex(words[:n], caseSensitive, minPrefix)
+	if err != nil {
+		return nil, err
+	}
+	return &packedWordsEncoding{bits: bits, words: words[:n], index: index}, nil
+}
+
+func (e *packedWordsEncoding) fingerprint() string {
+	return wordsFingerprint(e.words)
+}
+
+func (e *packedWordsEncoding) encode(data []byte) [][]string {
+	var lines [][]string
+	for len(data) > 0 {
+		n := 32
+		if n > len(data) {
+			n = len(data)
+		}
+
+		var line []string
+		acc, used := 0, 0
+		take := func() {
+			used -= e.bits
+			line = append(line, e.words[acc>>uint(used)])
+			acc &= 1<<uint(used) - 1
+		}
+		for _, b := range data[:n] {
+			acc, used = acc<<8|int(b), used+8
+			for used >= e.bits {
+				take()
+			}
+		}
+		acc, used = acc<<1|1, used+1
+		acc, used = acc<<uint(e.bits-used), e.bits
+		take()
+
+		lines = append(lines, line)
+		data = data[n:]
+	}
+	return lines
+}
+
+// decodeLine decodes a line, or lines put one after the other like in the
+// compact format. Every line but the last one of a share is 32 bytes, which
+// are always the same number of words.
+func (e *packedWordsEncoding) decodeLine(tokens []string) ([]byte, []unknownWord, error) {
+	perLine := (32*8 + e.bits) / e.bits
+	var data []byte
+	for start := 0; start < len(tokens); start += perLine {
+		end := start + perLine
+		if end > len(tokens) {
+			end = len(tokens)
+		}
+		decoded, err := e.decodeWords(tokens[start:end])
+		if we, ok := err.(*wordError); ok {
+			return nil, nil, &wordError{start + we.index, we.err}
+		} else if err != nil {
+			return nil, nil, err
+		}
+		data = append(data, decoded...)
+	}
+	return data, nil, nil
+}
+
+// decodeWords decodes the words of one line.
+func (e *packedWordsEncoding) decodeWords(tokens []string) ([]byte, error) {
+	var bits []byte
+	for n, w := range tokens {
+		v, ok, err := e.index.find(w)
+		if err != nil {
+			return nil, &wordError{n + 1, err}
+		}
+		if !ok {
+			return nil, &wordError{n + 1, fmt.Errorf("\"%s\" is not a word of the dictionary", w)}
+		}
+		for i := e.bits - 1; i >= 0; i-- {
+			bits = append(bits, byte(v>>uint(i)&1))
+		}
+	}
+
+	end := len(bits) - 1
+	for end >= 0 && bits[end] == 0 {
+		end--
+	}
+	if end < 0 || end%8 != 0 || len(bits)-end > e.bits {
+		return nil, fmt.Errorf("the line \"%s\" doesn't end the way the encoding does, some words are probably missing or wrong", strings.Join(tokens, " "))
+	}
+
+	var data []byte
+	for i := 0; i < end; i += 8 {
+		b := byte(0)
+		for _, bit := range bits[i : i+8] {
+			b = b<<1 | bit
+		}
+		data = append(data, b)
+	}
+	return data, nil
+}
+
+// bip39Words is the size of the BIP-39 word lists, 11 bits per word.
+const bip39Words = 2048
+
+// bip39Encoding is the packed words encoding with the BIP-39 English word
+// list, which has to be given with --dictionary. It is checked to be the
+// official list: 2048 words, starting with the built-in English words and
+// ending with "zoo".
+func (g *gsssa) bip39Encoding() (*packedWordsEncoding, error) {
+	if len(g.dictionary) == 0 {
+		return nil, fmt.Errorf("the bip39 encoding needs the BIP-39 English word list given with --dictionary")
+	}
+
+	words := g.getWordsFromDictionary(bip39Words)
+	english, err := embeddedWords("en")
+	if err != nil {
+		return nil, err
+	}
+	embedded := strings.Split(english, "\n")
+	isBIP39 := len(words) == bip39Words && words[bip39Words-1] == "zoo"
+	for i := 0; isBIP39 && i < len(embedded); i++ {
+		isBIP39 = words[i] == embedded[i]
+	}
+	if !isBIP39 {
+		return nil, fmt.Errorf("\"%s\" is not the BIP-39 English word list", g.dictionary)
+	}
+	return newPackedWordsEncoding(words, 11, g.caseSensitive, g.minPrefix)
+}
+
+// rawEncoding writes every share as the string sssa.Create returned, on one
+// line.
+type rawEncoding struct{}
+
+func (rawEncoding) encode(data []byte) [][]string {
+	return [][]string{{bytesToShare(data)}}
+}
+
+func (rawEncoding) decodeLine(tokens []string) ([]byte, []unknownWord, error) {
+	data, err := shareBytes(strings.Join(tokens, ""))
+	return data, nil, err
+}
+
+// hexEncoding writes every share as hex, in groups of two bytes and 16 bytes
+// per line.
+type hexEncoding struct{}
+
+func (hexEncoding) encode(data []byte) [][]string {
+	var lines [][]string
+	for len(data) > 0 {
+		n := 16
+		if n > len(data) {
+			n = len(data)
+		}
+
+		var line []string
+		for i := 0; i < n; i += 2 {
+			end := i + 2
+			if end > n {
+				end = n
+			}
+			line = append(line, hex.EncodeToString(data[i:end]))
+		}
+		lines = append(lines, line)
+		data = data[n:]
+	}
+	return lines
+}
+
+// decodeLine doesn't care how the hex digits are grouped or if they are upper
+// or lower case.
+func (hexEncoding) decodeLine(tokens []string) ([]byte, []unknownWord, error) {
+	digits := strings.Join(strings.Fields(strings.Join(tokens, " ")), "")
+	data, err := hex.DecodeString(digits)
+	if err != nil {
+		return nil, nil, fmt.Errorf("can't decode the hex line \"%s\": %v", strings.Join(tokens, " "), err)
+	}
+	return data, nil, nil
+}
+
+// base58Alphabet is the Bitcoin alphabet, without 0, O, I and l.
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// base58Encoding writes every 32 bytes of a share as one base58 chunk per
+// line.
+type base58Encoding struct{}
+
+func (base58Encoding) encode(data []byte) [][]string {
+	var lines [][]string
+	for len(data) > 0 {
+		n := 32
+		if n > len(data) {
+			n = len(data)
+		}
+		lines = append(lines, []string{encodeBase58(data[:n])})
+		data = data[n:]
+	}
+	return lines
+}
+
+func (base58Encoding) decodeLine(tokens []string) ([]byte, []unknownWord, error) {
+	var data []byte
+	for _, t := range tokens {
+		if len(t) == 0 {
+			continue
+		}
+		decoded, err := decodeBase58(t)
+		if err != nil {
+			return nil, nil, err
+		}
+		data = append(data, decoded...)
+	}
+	return data, nil, nil
+}
+
+// encodeBase58 encodes data with base58Alphabet. Leading zero bytes are
+// written as "1" so that they survive decoding.
+func encodeBase58(data []byte) string {
+	n := new(big.Int).SetBytes(data)
+	radix := big.NewInt(58)
+	mod := new(big.Int)
+
+	var out []byte
+	for n.Sign() > 0 {
+		n.DivMod(n, radix, mod)
+		out = append(out, base58Alphabet[mod.Int64()])
+	}
+	for _, b := range data {
+		if b != 0 {
+			break
+		}
+		out = append(out, base58Alphabet[0])
+	}
+
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return string(out)
+}
+
+// decodeBase58 is the opposite of encodeBase58.
+func decodeBase58(s string) ([]byte, error) {
+	n := new(big.Int)
+	radix := big.NewInt(58)
+	for _, c := range s {
+		i := strings.IndexRune(base58Alphabet, c)
+		if i < 0 {
+			return nil, fmt.Errorf("\"%c\" in \"%s\" is not a base58 character", c, s)
+		}
+		n.Mul(n, radix)
+		n.Add(n, big.NewInt(int64(i)))
+	}
+
+	zeros := 0
+	for zeros < len(s) && s[zeros] == base58Alphabet[0] {
+		zeros++
+	}
+	return append(make([]byte, zeros), n.Bytes()...), nil
+}
+
+// decimalEncoding writes every byte as a three digit number, 16 per line.
+type decimalEncoding struct{}
+
+func (decimalEncoding) encode(data []byte) [][]string {
+	var lines [][]string
+	for len(data) > 0 {
+		n := 16
+		if n > len(data) {
+			n = len(data)
+		}
+
+		var line []string
+		for _, b := range data[:n] {
+			line = append(line, fmt.Sprintf("%03d", b))
+		}
+		lines = append(lines, line)
+		data = data[n:]
+	}
+	return lines
+}
+
+// decodeLine only accepts numbers of up to three digits, without a sign.
+func (decimalEncoding) decodeLine(tokens []string) ([]byte, []unknownWord, error) {
+	var data []byte
+	for _, t := range tokens {
+		if len(t) == 0 {
+			continue
+		}
+		if strings.Trim(t, "0123456789") != "" {
+			return nil, nil, fmt.Errorf("\"%s\" is not a number, only the digits 0 to 9 can be in it", t)
+		}
+		if len(t) > 3 {
+			return nil, nil, fmt.Errorf("\"%s\" has more than three digits, every number is written with three", t)
+		}
+		n, _ := strconv.Atoi(t)
+		if n > 255 {
+			return nil, nil, fmt.Errorf("%s is out of range, every number must be between 0 and 255", t)
+		}
+		data = append(data, byte(n))
+	}
+	return data, nil, nil
+}
+
+// natoSymbols are the words of the NATO alphabet used for the 16 values of a
+// nibble.
+var natoSymbols = []string{
+	"ALFA", "BRAVO", "CHARLIE", "DELTA", "ECHO", "FOXTROT", "GOLF", "HOTEL",
+	"INDIA", "JULIETT", "KILO", "LIMA", "MIKE", "NOVEMBER", "OSCAR", "PAPA",
+}
+
+// natoEncoding writes every byte as two NATO alphabet words joined with a
+// hyphen, high nibble first, 8 bytes per line.
+type natoEncoding struct{}
+
+func (natoEncoding) encode(data []byte) [][]string {
+	var lines [][]string
+	for len(data) > 0 {
+		n := 8
+		if n > len(data) {
+			n = len(data)
+		}
+
+		var line []string
+		for _, b := range data[:n] {
+			line = append(line, natoSymbols[b>>4]+"-"+natoSymbols[b&0xf])
+		}
+		lines = append(lines, line)
+		data = data[n:]
+	}
+	return lines
+}
+
+// decodeLine accepts the words in any case, with or without the hyphen.
+// "ALPHA" and "JULIET" are also accepted as they are often spelled that way.
+func (natoEncoding) decodeLine(tokens []string) ([]byte, []unknownWord, error) {
+	var nibbles []byte
+	for _, t := range tokens {
+		for _, w := range strings.Split(t, "-") {
+			if len(w) == 0 {
+				continue
+			}
+			n, ok := natoValue(w)
+			if !ok {
+				return nil, nil, fmt.Errorf("\"%s\" is not one of the NATO alphabet words used for shares", w)
+			}
+			nibbles = append(nibbles, n)
+		}
+	}
+	if len(nibbles)%2 != 0 {
+		return nil, nil, fmt.Errorf("the line \"%s\" has an odd number of NATO alphabet words, but every byte is two words", strings.Join(tokens, " "))
+	}
+
+	var data []byte
+	for i := 0; i < len(nibbles); i += 2 {
+		data = append(data, nibbles[i]<<4|nibbles[i+1])
+	}
+	return data, nil, nil
+}
+
+// natoValue returns the nibble for a NATO alphabet word.
+func natoValue(w string) (byte, bool) {
+	w = strings.ToUpper(w)
+	switch w {
+	case "ALPHA":
+		w = "ALFA"
+	case "JULIET":
+		w = "JULIETT"
+	}
+	for i, s := range natoSymbols {
+		if s == w {
+			return byte(i), true
+		}
+	}
+	return 0, false
+}
+
+// diceEncoding writes every byte as the five dice index of a word in a
+// diceware list, 32 per line. Reveal accepts the index or the word.
+type diceEncoding struct {
+	indices       []string
+	words         []string        // as the list has them, for the fingerprint
+	values        map[string]byte // by dice index and by wordKey
+	caseSensitive bool
+}
+
+// diceEncoding reads the diceware list given with --dictionary. Every line of
+// it must be a five digit dice index followed by a word, like the EFF lists.
+// The first 256 lines are used.
+func (g *gsssa) diceEncoding() (*diceEncoding, error) {
+	if len(g.dictionary) == 0 {
+		return nil, fmt.Errorf("the dice encoding needs a diceware list given with --dictionary")
+	}
+
+	e := &diceEncoding{values: make(map[string]byte), caseSensitive: g.caseSensitive}
+	data := g.readDictionary()
+	g.checkDictionarySHA256(data, "\""+g.dictionary+"\"")
+	for i, line := range dictionaryLines(data) {
+		if len(e.indices) == 256 {
+			break
+		}
+		line = strings.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 || !isDiceIndex(fields[0]) {
+			return nil, fmt.Errorf("\"%s\" is not a diceware list, line %d should be a five digit dice index and a word: \"%s\"", g.dictionary, i+1, line)
+		}
+		index, word := fields[0], wordKey(fields[1], g.caseSensitive)
+		if _, ok := e.values[index]; ok {
+			return nil, fmt.Errorf("the dice index %s is in \"%s\" more than once", index, g.dictionary)
+		}
+		if _, ok := e.values[word]; ok {
+			return nil, fmt.Errorf("the word \"%s\" is in \"%s\" more than once, or only differs in case from another one. Use --case-sensitive for the latter", fields[1], g.dictionary)
+		}
+
+		b := byte(len(e.indices))
+		e.values[index], e.values[word] = b, b
+		e.indices = append(e.indices, index)
+		e.words = append(e.words, fields[1])
+	}
+
+	if len(e.indices) < 256 {
+		return nil, fmt.Errorf("\"%s\" needs to have at least 256 words. It only has: %d", g.dictionary, len(e.indices))
+	}
+	return e, nil
+}
+
+// isDiceIndex reports whether s is five throws of a die.
+func isDiceIndex(s string) bool {
+	if len(s) != 5 {
+		return false
+	}
+	for _, c := range s {
+		if c < '1' || c > '6' {
+			return false
+		}
+	}
+	return true
+}
+
+// fingerprint is of the words as the list has them, so it doesn't depend on
+// --case-sensitive.
+func (e *diceEncoding) fingerprint() string {
+	return wordsFingerprint(e.words)
+}
+
+func (e *diceEncoding) encode(data []byte) [][]string {
+	var lines [][]string
+	for len(data) > 0 {
+		n := 32
+		if n > len(data) {
+			n = len(data)
+		}
+
+		var line []string
+		for _, b := range data[:n] {
+			line = append(line, e.indices[b])
+		}
+		lines = append(lines, line)
+		data = data[n:]
+	}
+	return lines
+}
+
+func (e *diceEncoding) decodeLine(tokens []string) ([]byte, []unknownWord, error) {
+	var data []byte
+	for _, t := range tokens {
+		if len(t) == 0 {
+			continue
+		}
+		b, ok := e.values[wordKey(t, e.caseSensitive)]
+		if !ok {
+			return nil, nil, fmt.Errorf("\"%s\" is neither a dice index nor a word of the diceware list", t)
+		}
+		data = append(data, b)
+	}
+	return data, nil, nil
+}
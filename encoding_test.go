@@ -0,0 +1,272 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// The bits of a line of the bip39 encoding, which is the packed words
+// encoding with 11 bits, are followed by a 1 bit and 0 bits up to the end of
+// the last word, for every number of bytes.
+func TestBIP39Padding(t *testing.T) {
+	var words []string
+	for i := 0; i < bip39Words; i++ {
+		words = append(words, fmt.Sprintf("word%04d", i))
+	}
+	enc, err := newPackedWordsEncoding(words, 11, false, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every number of bytes mod 11, with the words it needs: 8 bits per
+	// byte, and the 1 bit, in words of 11 bits.
+	tests := []struct {
+		bytes, words int
+	}{
+		{1, 1},
+		{2, 2},
+		{3, 3},
+		{4, 3},
+		{5, 4},
+		{6, 5},
+		{7, 6},
+		{8, 6},
+		{9, 7},
+		{10, 8},
+		{11, 9},
+		{12, 9},
+		{13, 10},
+		{14, 11},
+		{15, 11},
+		{16, 12},
+		{17, 13},
+		{18, 14},
+		{19, 14},
+		{20, 15},
+		{21, 16},
+		{22, 17},
+		{32, 24},
+	}
+	for _, test := range tests {
+		data := make([]byte, test.bytes)
+		for i := range data {
+			data[i] = byte(0xff - i*7)
+		}
+
+		lines := enc.encode(data)
+		if len(lines) != 1 || len(lines[0]) != test.words {
+			t.Errorf("%d bytes: encoded as %v, want one line of %d words", test.bytes, lines, test.words)
+			continue
+		}
+		decoded, _, err := enc.decodeLine(lines[0])
+		if err != nil {
+			t.Errorf("%d bytes: %v", test.bytes, err)
+			continue
+		}
+		if !bytes.Equal(decoded, data) {
+			t.Errorf("%d bytes: decoded %x, want %x", test.bytes, decoded, data)
+		}
+
+		// More padding than a word is an error.
+		if _, _, err := enc.decodeLine(append(append([]string{}, lines[0]...), enc.words[0])); err == nil {
+			t.Errorf("%d bytes: a line with an extra word was decoded", test.bytes)
+		}
+	}
+}
+
+// The words encoding reads words that aren't in the dictionary anyway, and
+// returns them with the line they are on.
+func TestWordsUnknownWords(t *testing.T) {
+	enc, err := testGsssa().shareEncoding(defaultEncoding)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, unknown, err := enc.decodeLine([]string{"abandon", "abandxn", "ability"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, []byte{0, 0, 1}) {
+		t.Errorf("decoded %x, want 000001", data)
+	}
+	if len(unknown) != 1 || unknown[0].word != "abandxn" || unknown[0].index != 2 || len(unknown[0].suggestions) == 0 || unknown[0].suggestions[0] != "abandon" {
+		t.Errorf("unknown words %+v, want \"abandxn\" as word 2, did you mean abandon", unknown)
+	}
+
+	// They are only returned for the line they are on.
+	if _, unknown, err := enc.decodeLine([]string{"abandon", "ability"}); err != nil || len(unknown) > 0 {
+		t.Errorf("a line of dictionary words: unknown words %v, error %v", unknown, err)
+	}
+}
+
+// decodeTests decodes every line of tests with enc, and checks the bytes or
+// that it is an error.
+func decodeTests(t *testing.T, enc shareEncoding, tests []decodeTest) {
+	t.Helper()
+	for _, test := range tests {
+		data, _, err := enc.decodeLine(strings.Fields(test.line))
+		switch {
+		case test.err && err == nil:
+			t.Errorf("%q: decoded %x, want an error", test.line, data)
+		case !test.err && err != nil:
+			t.Errorf("%q: %v", test.line, err)
+		case !test.err && !bytes.Equal(data, []byte(test.data)):
+			t.Errorf("%q: decoded %x, want %x", test.line, data, test.data)
+		}
+	}
+}
+
+// decodeTest is a line of a share and the bytes it is, or else an error.
+type decodeTest struct {
+	line string
+	data string
+	err  bool
+}
+
+// The hex of a share can be written in any case and grouped in any way.
+func TestHexDecode(t *testing.T) {
+	decodeTests(t, hexEncoding{}, []decodeTest{
+		{"a3f0 9c21", "\xa3\xf0\x9c\x21", false},
+		{"A3F0 9C21", "\xa3\xf0\x9c\x21", false},
+		{"a3 f0 9c 21", "\xa3\xf0\x9c\x21", false},
+		{"a3f09c21", "\xa3\xf0\x9c\x21", false},
+		{"a3f  09c2 1", "\xa3\xf0\x9c\x21", false},
+		{"a3f0 9c2", "", true},
+		{"a3f0 9g21", "", true},
+		{"a3f0-9c21", "", true},
+	})
+
+	data := make([]byte, 37)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+	lines := hexEncoding{}.encode(data)
+	if len(lines) != 3 || len(lines[0]) != 8 || lines[0][1] != "0e15" {
+		t.Errorf("encoded as %v, want 16 bytes per line in groups of two", lines)
+	}
+}
+
+// NATO alphabet words are read in any case, with or without the hyphen
+// between the two of a byte, and in their common spellings.
+func TestNATODecode(t *testing.T) {
+	decodeTests(t, natoEncoding{}, []decodeTest{
+		{"ALFA-BRAVO CHARLIE-DELTA", "\x01\x23", false},
+		{"alfa-bravo charlie-delta", "\x01\x23", false},
+		{"Alfa Bravo Charlie Delta", "\x01\x23", false},
+		{"ALFA BRAVO-CHARLIE DELTA", "\x01\x23", false},
+		{"ALPHA-JULIET", "\x09", false},
+		{"PAPA-PAPA OSCAR-ALFA", "\xff\xe0", false},
+		{"ALFA-BRAVO CHARLIE", "", true},
+		{"ALFA-ROMEO", "", true},
+		{"ALFA-", "", true},
+	})
+
+	data := make([]byte, 20)
+	for i := range data {
+		data[i] = byte(i * 13)
+	}
+	lines := natoEncoding{}.encode(data)
+	if len(lines) != 3 || len(lines[0]) != 8 || lines[0][1] != "ALFA-NOVEMBER" {
+		t.Errorf("encoded as %v, want 8 bytes per line as two words each", lines)
+	}
+	decoded, _, err := natoEncoding{}.decodeLine(append(append(lines[0], lines[1]...), lines[2]...))
+	if err != nil || !bytes.Equal(decoded, data) {
+		t.Errorf("decoded %x (%v), want %x", decoded, err, data)
+	}
+}
+
+// Words are found in any case, unless with --case-sensitive, which is for
+// dictionaries with words that only differ in case.
+func TestWordsCase(t *testing.T) {
+	enc, err := testGsssa().shareEncoding(defaultEncoding)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		words         string
+		caseSensitive bool
+		unknown       int
+	}{
+		{"abandon ability able", false, 0},
+		{"ABANDON ABILITY ABLE", false, 0},
+		{"Abandon Ability Able", false, 0},
+		{"aBANDON abiLity ABLE", false, 0},
+		{"abandon ability able", true, 0},
+		{"ABANDON Ability able", true, 2},
+	}
+	for _, test := range tests {
+		words, err := newWordsEncoding(enc.(*wordsEncoding).index.words, test.caseSensitive, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, unknown, err := words.decodeLine(strings.Fields(test.words))
+		if err != nil || len(unknown) != test.unknown {
+			t.Errorf("%q, --case-sensitive %v: unknown words %v, error %v, want %d unknown", test.words, test.caseSensitive, unknown, err, test.unknown)
+			continue
+		}
+		if test.unknown == 0 && !bytes.Equal(data, []byte{0, 1, 2}) {
+			t.Errorf("%q, --case-sensitive %v: decoded %x, want 000102", test.words, test.caseSensitive, data)
+		}
+	}
+
+	dictionary := append([]string{"Qwerty"}, enc.(*wordsEncoding).index.words[:255]...)
+	dictionary[1] = "qwerty"
+	if _, err := newWordsEncoding(dictionary, false, 0); err == nil || !strings.Contains(err.Error(), "use --case-sensitive") {
+		t.Errorf("words that only differ in case: got %v, want --case-sensitive", err)
+	}
+	words, err := newWordsEncoding(dictionary, true, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _, err := words.decodeLine([]string{"qwerty", "Qwerty"}); err != nil || !bytes.Equal(data, []byte{1, 0}) {
+		t.Errorf("with --case-sensitive: decoded %x (%v), want 0100", data, err)
+	}
+}
+
+// A word that isn't in the dictionary gets the closest words as
+// suggestions, and is read as the word one letter away with --auto-correct
+// when there is only one.
+func TestWordsSuggestions(t *testing.T) {
+	enc, err := testGsssa().shareEncoding(defaultEncoding)
+	if err != nil {
+		t.Fatal(err)
+	}
+	words, err := newWordsEncoding(enc.(*wordsEncoding).index.words, false, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	words.autoCorrect = true
+	tests := []struct {
+		word        string
+		suggestions string
+		correction  string
+	}{
+		{"abandom", "abandon", "abandon"},  // a letter changed
+		{"abandno", "abandon", "abandon"},  // two letters swapped
+		{"abanon", "abandon", "abandon"},   // a letter missing
+		{"abandoon", "abandon", "abandon"}, // a letter too many
+		{"aple", "able apple", ""},         // two words one letter away
+		{"abbandn", "abandon", ""},         // two letters away
+		{"xyzzyq", "", ""},                 // nothing close
+		{"ABANDOM", "abandon", "abandon"},  // in capitals
+	}
+	for _, test := range tests {
+		data, unknown, err := words.decodeLine([]string{"ability", test.word})
+		if err != nil || len(unknown) != 1 {
+			t.Fatalf("%q: unknown words %v, error %v", test.word, unknown, err)
+		}
+		u := unknown[0]
+		if strings.Join(u.suggestions, " ") != test.suggestions || u.correction != test.correction || u.index != 2 {
+			t.Errorf("%q: suggestions %v, correction %q at word %d, want %q and %q at word 2", test.word, u.suggestions, u.correction, u.index, test.suggestions, test.correction)
+		}
+		if want := []byte{1, 0}; len(test.correction) > 0 && !bytes.Equal(data, want) {
+			t.Errorf("%q: decoded %x, want %x", test.word, data, want)
+		}
+	}
+
+	u := unknownWord{word: "recieve", share: "share 2", line: 1, index: 17, suggestions: []string{"receive"}}
+	if want := "share 2, line 1, word 17: \"recieve\", did you mean: receive?"; u.String() != want {
+		t.Errorf("got %q, want %q", u.String(), want)
+	}
+}
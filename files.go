@@ -0,0 +1,471 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// outputFilenames returns the files create writes to. Normally all shares go
+// into --file, with --split or --holders every share gets its own file next
+// to it, with --dest in its own destination, and with --file-pattern every
+// share gets the file the pattern names.
+func (g *gsssa) outputFilenames() []string {
+	if len(g.filePattern) > 0 {
+		var names []string
+		for i := 1; i <= g.createAmount; i++ {
+			names = append(names, g.expandFilePattern(i))
+		}
+		return names
+	}
+
+	if !g.split && len(g.holders) == 0 && len(g.dests) == 0 {
+		return []string{g.sharesFilename}
+	}
+
+	ext := ".txt"
+	if g.format == "json" || g.format == "csv" {
+		ext = "." + g.format
+	}
+	dir := filepath.Dir(g.sharesFilename)
+
+	var names []string
+	for i := 1; i <= g.createAmount; i++ {
+		label := fmt.Sprint(i)
+		if len(g.holders) > 0 {
+			label = g.holders[i-1]
+		}
+		if len(g.dests) > 0 {
+			dir = g.dests[i-1]
+		}
+		names = append(names, filepath.Join(dir, "share-"+label+ext))
+	}
+	return names
+}
+
+// stdoutFile as --file writes the shares to stdout and no file.
+const stdoutFile = "-"
+
+// messages is where create shows what it did. It is stderr when the shares
+// go to stdout, so they can be piped on as they are.
+func (g *gsssa) messages() io.Writer {
+	if g.sharesFilename == stdoutFile {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// parseDests checks the --dest directories. They must exist already, so a
+// share isn't written to the disk of this machine when a USB stick isn't
+// mounted.
+func (g *gsssa) parseDests(list string) {
+	if len(list) == 0 {
+		return
+	}
+	if len(g.filePattern) > 0 {
+		fmt.Fprintf(os.Stderr, "Only one of --dest and --file-pattern can be used.\n")
+		os.Exit(1)
+	}
+
+	for _, dir := range strings.Split(list, ",") {
+		dir = strings.TrimSpace(dir)
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			fmt.Fprintf(os.Stderr, "The destination \"%s\" is not a directory. Is it mounted?\n", dir)
+			os.Exit(1)
+		}
+		g.dests = append(g.dests, dir)
+	}
+
+	if len(g.dests) != g.createAmount {
+		fmt.Fprintf(os.Stderr, "There are %d destinations, but %d shares are created. Give one destination per share, or change --amount.\n", len(g.dests), g.createAmount)
+		os.Exit(1)
+	}
+}
+
+// writeShareFile writes content to name and reads it back to make sure it
+// really got there.
+func (g *gsssa) writeShareFile(name, content string) error {
+	if len(g.section) > 0 {
+		return g.appendSection(name, content)
+	}
+	if len(g.dests) == 0 {
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			return err
+		}
+	}
+	if err := ioutil.WriteFile(name, []byte(content), 0644); err != nil {
+		return err
+	}
+
+	written, err := ioutil.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	if string(written) != content {
+		return fmt.Errorf("what was read back from \"%s\" is not what was written", name)
+	}
+	return nil
+}
+
+// parseHolders checks the --holders names and makes them safe to use in
+// filenames and comments.
+func (g *gsssa) parseHolders(list string) {
+	if len(list) == 0 {
+		return
+	}
+
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(list, ",") {
+		holder := sanitizeName(name)
+		if len(holder) == 0 {
+			fmt.Fprintf(os.Stderr, "The holder name \"%s\" has no usable characters.\n", strings.TrimSpace(name))
+			os.Exit(1)
+		}
+		if seen[strings.ToLower(holder)] {
+			fmt.Fprintf(os.Stderr, "The holder \"%s\" is given more than once.\n", holder)
+			os.Exit(1)
+		}
+		seen[strings.ToLower(holder)] = true
+		g.holders = append(g.holders, holder)
+	}
+
+	if len(g.holders) != g.createAmount {
+		fmt.Fprintf(os.Stderr, "There are %d holders, but %d shares are created. Give one holder per share, or change --amount.\n", len(g.holders), g.createAmount)
+		os.Exit(1)
+	}
+}
+
+// labelPattern is what a --labels label can be made of.
+var labelPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,32}$`)
+
+// parseLabels checks the --labels. A share can be left without a label by
+// leaving its place in the list empty.
+func (g *gsssa) parseLabels(list string) {
+	if len(list) == 0 {
+		return
+	}
+	if len(g.holders) > 0 {
+		fmt.Fprintf(os.Stderr, "Only one of --holders and --labels can be used, the holder names already label the shares.\n")
+		os.Exit(1)
+	}
+
+	for _, label := range strings.Split(list, ",") {
+		label = strings.TrimSpace(label)
+		if len(label) > 0 && !labelPattern.MatchString(label) {
+			fmt.Fprintf(os.Stderr, "The label \"%s\" can only have up to 32 letters, digits, dots, dashes and underscores.\n", label)
+			os.Exit(1)
+		}
+		g.labels = append(g.labels, label)
+	}
+
+	if len(g.labels) != g.createAmount {
+		fmt.Fprintf(os.Stderr, "There are %d labels, but %d shares are created. Give one label per share, an empty one for none, or change --amount.\n", len(g.labels), g.createAmount)
+		os.Exit(1)
+	}
+}
+
+// sanitizeName keeps letters, digits, dots, dashes and underscores of a name
+// and turns everything else into dashes.
+func sanitizeName(name string) string {
+	mapped := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '_', r == '-':
+			return r
+		}
+		return '-'
+	}, strings.TrimSpace(name))
+	return strings.Trim(mapped, "-.")
+}
+
+// expandFilePattern returns the --file-pattern filename of share n.
+func (g *gsssa) expandFilePattern(n int) string {
+	label := fmt.Sprint(n)
+	if len(g.holders) > 0 {
+		label = g.holders[n-1]
+	}
+
+	return strings.NewReplacer(
+		"{n}", fmt.Sprint(n),
+		"{label}", label,
+		"{amount}", fmt.Sprint(g.createAmount),
+		"{min}", fmt.Sprint(g.createMin),
+		"{date}", g.created.Format("2006-01-02"),
+	).Replace(g.filePattern)
+}
+
+// checkFilePattern makes sure --file-pattern only uses known placeholders
+// and gives every share its own file.
+func (g *gsssa) checkFilePattern() {
+	if len(g.filePattern) == 0 {
+		return
+	}
+
+	rest := g.filePattern
+	for _, p := range []string{"{n}", "{label}", "{amount}", "{min}", "{date}"} {
+		rest = strings.Replace(rest, p, "", -1)
+	}
+	if strings.ContainsAny(rest, "{}") {
+		fmt.Fprintf(os.Stderr, "The file pattern \"%s\" has an unknown placeholder. Known are {n}, {label}, {amount}, {min} and {date}.\n", g.filePattern)
+		os.Exit(1)
+	}
+
+	seen := make(map[string]int)
+	for i, name := range g.outputFilenames() {
+		clean := filepath.Clean(name)
+		if other, ok := seen[clean]; ok {
+			fmt.Fprintf(os.Stderr, "The file pattern \"%s\" gives share %d and share %d the same file \"%s\". Use {n} or {label} in it.\n", g.filePattern, other, i+1, name)
+			os.Exit(1)
+		}
+		seen[clean] = i + 1
+	}
+}
+
+// checkOutputFiles makes sure no existing shares file gets overwritten
+// without --force, before anything is done.
+func (g *gsssa) checkOutputFiles() {
+	g.checkFilePattern()
+	if g.qrOnly && len(g.qrDir) == 0 {
+		fmt.Fprintf(os.Stderr, "--qr-only needs --qr-dir.\n")
+		os.Exit(1)
+	}
+	if g.sharesFilename == stdoutFile {
+		if g.split || len(g.holders) > 0 || len(g.dests) > 0 || len(g.filePattern) > 0 || len(g.archive) > 0 {
+			fmt.Fprintf(os.Stderr, "With -f - the shares go to stdout, so --split, --holders, --dest, --file-pattern and --archive can't be used.\n")
+			os.Exit(1)
+		}
+		return
+	}
+	if len(g.archive) > 0 && !g.forceOverwrite {
+		if _, err := os.Stat(g.archive); !os.IsNotExist(err) {
+			fmt.Fprintf(os.Stderr, "The archive \"%s\" already exists. To force overwriting, use --force flag.\n", g.archive)
+			os.Exit(1)
+		}
+	}
+	if len(g.section) > 0 {
+		g.checkSection()
+		return
+	}
+	if g.forceOverwrite || g.qrOnly {
+		return
+	}
+	for _, name := range g.outputFilenames() {
+		if _, err := os.Stat(name); !os.IsNotExist(err) {
+			fmt.Fprintf(os.Stderr, "The shares file \"%s\" already exists. To force overwriting, use --force flag. This is done so a potential previous created shares file isn't overwritten by mistake.\n", name)
+			os.Exit(1)
+		}
+	}
+}
+
+// render renders the shares in the chosen --format.
+func (g *gsssa) render(set *shareSet) (string, error) {
+	switch g.format {
+	case "json":
+		return renderJSON(set)
+	case "csv":
+		return renderCSV(set)
+	case "armor":
+		return renderArmor(set), nil
+	case "compact":
+		return renderCompact(set), nil
+	}
+	return renderText(set), nil
+}
+
+// writeShares writes everything asked for: the shares files, the PDF sheets,
+// the QR codes and the archive of them.
+func (g *gsssa) writeShares(set *shareSet) {
+	if len(g.pdfDir) > 0 {
+		g.writePDFs(set)
+	}
+	if len(g.qrDir) > 0 {
+		g.writeQRCodes(set)
+	}
+	if !g.qrOnly {
+		g.writeShareFiles(set)
+	}
+	if len(g.archive) > 0 {
+		g.writeArchive()
+	}
+}
+
+// writeShareFiles writes the shares to the output files and shows what was
+// written.
+func (g *gsssa) writeShareFiles(set *shareSet) {
+	names := g.outputFilenames()
+	var written, failed []string
+
+	for i, name := range names {
+		part := set
+		if len(names) > 1 {
+			part = shareOnly(set, i)
+		}
+
+		content, err := g.render(part)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if name != stdoutFile {
+			if err := g.writeShareFile(name, content); err != nil {
+				if len(g.dests) == 0 {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+				failed = append(failed, fmt.Sprintf("share %d to \"%s\": %v", i+1, name, err))
+				continue
+			}
+			written = append(written, fmt.Sprintf("share %d to \"%s\"", i+1, name))
+			g.archived = append(g.archived, archivedFile{name, filepath.Base(name)})
+		}
+
+		if g.printShares() {
+			g.printShareFile(part, content, name, len(names) > 1)
+		}
+	}
+
+	if len(failed) > 0 {
+		fmt.Fprintf(os.Stderr, "\nNot all shares could be written.\n")
+		if len(written) > 0 {
+			fmt.Fprintf(os.Stderr, "Written and read back: %s.\n", strings.Join(written, ", "))
+		}
+		fmt.Fprintf(os.Stderr, "NOT written: %s.\n", strings.Join(failed, ", "))
+		os.Exit(1)
+	}
+
+	if names[0] == stdoutFile {
+		return
+	}
+	if !g.printShares() {
+		fmt.Printf("%d shares, %d required, written to %s.\n", set.amount, set.min, strings.Join(names, ", "))
+		return
+	}
+	if len(names) == 1 {
+		fmt.Printf("\n The file \"%s\" is now created with above shown information.\n\n", names[0])
+	} else {
+		fmt.Printf("\n The files \"%s\" are now created with above shown information. Give each holder only their own file.\n\n", strings.Join(names, "\", \""))
+	}
+}
+
+// printShares reports whether the shares are shown on stdout as well. They
+// are on a terminal, unless --no-print is given, and not otherwise, unless
+// --print is given. With -f - they always are, as that is all that is
+// written.
+func (g *gsssa) printShares() bool {
+	switch {
+	case g.sharesFilename == stdoutFile:
+		return true
+	case g.noPrint:
+		return false
+	case g.forcePrint:
+		return true
+	}
+	return isTerminal(os.Stdout)
+}
+
+// printShareFile shows what was written to the file name, with the QR codes
+// of --qr-terminal after the shares.
+func (g *gsssa) printShareFile(set *shareSet, content, name string, several bool) {
+	if several {
+		fmt.Printf("==> %s <==\n", name)
+	}
+	if g.printQRCodes() && g.format == "text" {
+		fmt.Print(textHeader(set))
+		for j, share := range set.shares {
+			fmt.Print(textShare(set, share))
+			g.printQRCode(set, j)
+		}
+		fmt.Print(textFooter(set))
+	} else {
+		fmt.Print(content)
+		if g.printQRCodes() {
+			for j := range set.shares {
+				g.printQRCode(set, j)
+			}
+		}
+	}
+	if several {
+		fmt.Println()
+	}
+}
+
+// collectSharesFiles returns the shares files reveal reads for the --file
+// names. A directory stands for the files in it and a name that isn't a file
+// is a glob pattern, both in the order of their names. Their files that
+// aren't shares files, like a README, are skipped.
+func (g *gsssa) collectSharesFiles(names []string) ([]string, error) {
+	var files []string
+	for _, name := range names {
+		var matches []string
+		info, err := os.Stat(name)
+		switch {
+		case err == nil && info.IsDir():
+			entries, err := ioutil.ReadDir(name)
+			if err != nil {
+				return nil, err
+			}
+			for _, e := range entries {
+				matches = append(matches, filepath.Join(name, e.Name()))
+			}
+		case err != nil && strings.ContainsAny(name, "*?["):
+			matches, err = filepath.Glob(name)
+			if err != nil {
+				return nil, fmt.Errorf("\"%s\" is not a valid pattern: %v", name, err)
+			}
+			sort.Strings(matches)
+		default:
+			files = append(files, name)
+			continue
+		}
+
+		found := 0
+		for _, m := range matches {
+			if info, err := os.Stat(m); err != nil || !info.Mode().IsRegular() {
+				continue
+			}
+			if !g.isSharesFile(m) {
+				fmt.Fprintf(os.Stderr, "NOTE: Skipped \"%s\", it is not a shares file.\n", m)
+				continue
+			}
+			files = append(files, m)
+			found++
+		}
+		if found == 0 {
+			return nil, fmt.Errorf("there are no shares files in \"%s\"", name)
+		}
+	}
+	return files, nil
+}
+
+// isSharesFile tells whether the file looks like a shares file, of any
+// format.
+func (g *gsssa) isSharesFile(filename string) bool {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil || !utf8.Valid(data) || bytes.IndexByte(data, 0) >= 0 {
+		return false
+	}
+	text := strings.Replace(string(data), "\r\n", "\n", -1)
+	if sharesFormat(g.format, text) != "text" || strings.Contains(text, headerPrefix) || strings.Contains(text, "# Share ") {
+		return true
+	}
+
+	// Files of the first gsssa versions are only words, some of them should
+	// be in the dictionary.
+	lang := g.lang
+	defer func() { g.lang = lang }()
+	parsed, err := g.parseSharesData(text)
+	if err != nil || len(parsed.shares) == 0 {
+		return false
+	}
+	words := 0
+	for _, s := range parsed.shares {
+		words += s.words
+	}
+	return len(parsed.unknownWords) < words
+}
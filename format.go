@@ -0,0 +1,266 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// shareSet holds everything that goes into a shares file.
+type shareSet struct {
+	min        int
+	amount     int
+	dictionary string
+	dictFP     string // fingerprint of the dictionary, empty without one
+	encoding   string
+	checksum   bool
+	note       string // from --note or --note-file
+	created    string // RFC 3339, empty with --no-timestamp
+	lang       string // of the built-in word list, empty for the default one
+	secretFP   string // fingerprint of the secret, empty without --fingerprint
+	lineCRC    bool   // every line ends with a checksum of it
+	threshold  bool   // every share starts with its min and amount
+	shares     []createdShare
+
+	groupSize      int // words between group separators in the text format, 0 for none
+	groupSeparator string
+	perLine        int // words on a full line from --words-per-line, 0 for the default
+}
+
+// Lines in the text format can have their words grouped by a separator,
+// which reveal ignores. The default one is always ignored, the header line
+// names other ones.
+const defaultGroupSeparator = "/"
+
+// Text files start with a header line like
+//
+//	# gsssa v2 min=2 amount=5 encoding=words checksum=sha256-4 created=2017-06-01T12:00:00Z
+//
+// Files from before it only have the footer.
+const (
+	headerPrefix      = "# gsssa v"
+	textFormatVersion = 2
+)
+
+// fileChecksumPrefix starts the last line of text files, with a checksum of
+// all the share lines in the file.
+const fileChecksumPrefix = "# file-checksum: "
+
+// notePrefix starts the comment lines of a note. It can't be mistaken for any
+// of the other comment lines, whatever the note says.
+const notePrefix = "#| "
+
+// createdShare is one share, encoded as lines of words.
+type createdShare struct {
+	number int
+	holder string // from --holders, empty if not given
+	label  string // from --labels, empty if not given
+	data   []byte
+	lines  [][]string
+}
+
+// rewrapLines puts the tokens of lines on new lines of perLine tokens each.
+// The last line can be shorter.
+func rewrapLines(lines [][]string, perLine int) [][]string {
+	var tokens []string
+	for _, line := range lines {
+		tokens = append(tokens, line...)
+	}
+
+	var wrapped [][]string
+	for len(tokens) > 0 {
+		n := perLine
+		if n > len(tokens) {
+			n = len(tokens)
+		}
+		wrapped = append(wrapped, tokens[:n])
+		tokens = tokens[n:]
+	}
+	return wrapped
+}
+
+// groupWords puts separator between every size words of line.
+func groupWords(line []string, size int, separator string) []string {
+	if size <= 0 {
+		return line
+	}
+
+	var grouped []string
+	for i, w := range line {
+		if i > 0 && i%size == 0 {
+			grouped = append(grouped, separator)
+		}
+		grouped = append(grouped, w)
+	}
+	return grouped
+}
+
+// renderText renders the shares in the original format: a comment before
+// every share, its word lines, and a blank line after it.
+func renderText(set *shareSet) string {
+	text := textHeader(set)
+	for _, share := range set.shares {
+		text += textShare(set, share)
+	}
+	return text + textFooter(set)
+}
+
+// textHeader is what comes before the shares in the text format: the header
+// line with what reveal needs to know, and the note.
+func textHeader(set *shareSet) string {
+	header := fmt.Sprintf("%s%d min=%d amount=%d encoding=%s", headerPrefix, textFormatVersion, set.min, set.amount, set.encoding)
+	if len(set.lang) > 0 {
+		header += " lang=" + set.lang
+	}
+	if len(set.dictFP) > 0 {
+		header += " dictionary-fingerprint=" + set.dictFP
+	}
+	if set.checksum {
+		header += " checksum=sha256-4"
+	}
+	if set.threshold {
+		header += " share-prefix=threshold-1"
+	}
+	if set.lineCRC {
+		header += " line-checksums=crc32-1"
+	}
+	if set.groupSize > 0 {
+		header += " group-separator=" + set.groupSeparator
+	}
+	if set.perLine > 0 {
+		header += fmt.Sprintf(" words-per-line=%d", set.perLine)
+	}
+	if len(set.secretFP) > 0 {
+		header += " fingerprint=" + set.secretFP
+	}
+	if len(set.created) > 0 {
+		header += " created=" + set.created
+	}
+	header += "\n"
+
+	if len(set.note) > 0 {
+		for _, line := range strings.Split(set.note, "\n") {
+			header += strings.TrimRight(notePrefix+line, " ") + "\n"
+		}
+	}
+	return header + "\n"
+}
+
+// textShare is one share block in the text format.
+func textShare(set *shareSet, share createdShare) string {
+	var buff bytes.Buffer
+	if len(share.holder) > 0 {
+		fmt.Fprintf(&buff, "# Share for: %s (%d of %d, need %d)\n", share.holder, share.number, set.amount, set.min)
+	} else if len(share.label) > 0 {
+		fmt.Fprintf(&buff, "# Share %d (%s)\n", share.number, share.label)
+	} else {
+		fmt.Fprintf(&buff, "# Share %d\n", share.number)
+	}
+	for _, line := range share.lines {
+		buff.WriteString(strings.Join(groupWords(line, set.groupSize, set.groupSeparator), " ") + "\n")
+	}
+	buff.WriteString("\n")
+	return buff.String()
+}
+
+// textFooter is what comes after the shares in the text format, ending with
+// the file checksum.
+func textFooter(set *shareSet) string {
+	shares := ""
+	for _, share := range set.shares {
+		shares += textShare(set, share)
+	}
+	return fmt.Sprintf("# You need %d shares out of these %d shares to be able to get your secret back.\n%s%s\n", set.min, set.amount, fileChecksumPrefix, fileChecksum(shares, nil))
+}
+
+// fileChecksum is the checksum of the words on the lines with share data in
+// text, that is all but the comments and blank lines: the first 4 bytes of
+// the SHA-256 of them, in hex. The words are looked at like dictionary words
+// and without the whitespace between them, so changes in case, Unicode
+// normalization, spacing and line breaks don't count. With enc the words are
+// the dictionary words they are read as, so neither does writing only their
+// start.
+func fileChecksum(text string, enc shareEncoding) string {
+	var words []string
+	for _, s := range unwrapLines(text) {
+		if len(s) > 0 && s[0] != '#' {
+			for _, w := range strings.Fields(s) {
+				words = append(words, wordKey(resolveWord(enc, w), false))
+			}
+		}
+	}
+	sum := sha256.Sum256([]byte(strings.Join(words, " ")))
+	return "sha256-" + hex.EncodeToString(sum[:4])
+}
+
+// jsonShares is the JSON format of a shares file. Fields are only ever
+// added, so tools reading it keep working.
+type jsonShares struct {
+	Format     string      `json:"format"`
+	Version    int         `json:"version"`
+	Min        int         `json:"min"`
+	Amount     int         `json:"amount"`
+	Dictionary string      `json:"dictionary"`
+	DictFP     string      `json:"dictionary_fingerprint,omitempty"`
+	Lang       string      `json:"lang,omitempty"`
+	Encoding   string      `json:"encoding"`
+	Checksum   string      `json:"checksum,omitempty"`
+	Prefix     string      `json:"share_prefix,omitempty"`
+	Note       string      `json:"note,omitempty"`
+	Created    string      `json:"created,omitempty"`
+	SecretFP   string      `json:"fingerprint,omitempty"`
+	Shares     []jsonShare `json:"shares"`
+}
+
+type jsonShare struct {
+	Index  int        `json:"index"`
+	Holder string     `json:"holder,omitempty"`
+	Label  string     `json:"label,omitempty"`
+	Lines  [][]string `json:"lines"`
+}
+
+const (
+	jsonFormatName    = "gsssa-shares"
+	jsonFormatVersion = 1
+)
+
+// renderJSON renders the shares as a jsonShares document.
+func renderJSON(set *shareSet) (string, error) {
+	doc := jsonShares{
+		Format:     jsonFormatName,
+		Version:    jsonFormatVersion,
+		Min:        set.min,
+		Amount:     set.amount,
+		Dictionary: set.dictionary,
+		DictFP:     set.dictFP,
+		Lang:       set.lang,
+		Encoding:   set.encoding,
+		Note:       set.note,
+		Created:    set.created,
+		SecretFP:   set.secretFP,
+		Shares:     []jsonShare{},
+	}
+	if set.checksum {
+		doc.Checksum = "sha256-4"
+	}
+	if set.threshold {
+		doc.Prefix = "threshold-1"
+	}
+	for _, share := range set.shares {
+		doc.Shares = append(doc.Shares, jsonShare{Index: share.number, Holder: share.holder, Label: share.label, Lines: share.lines})
+	}
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data) + "\n", nil
+}
+
+// isJSONShares guesses whether the contents of a shares file are JSON.
+func isJSONShares(data string) bool {
+	return strings.HasPrefix(strings.TrimSpace(data), "{")
+}
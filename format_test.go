@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "write the golden files of the tests")
+
+// goldenSet is a set of shares with fixed bytes, timestamp and fingerprint
+// salt, so it always renders the same.
+func goldenSet(t *testing.T) *shareSet {
+	t.Helper()
+	g := testGsssa()
+	enc, err := g.shareEncoding(defaultEncoding)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	set := &shareSet{
+		min:        2,
+		amount:     3,
+		dictionary: g.dictionaryName(),
+		dictFP:     encodingFingerprint(enc),
+		encoding:   defaultEncoding,
+		checksum:   true,
+		note:       "Kept in the safe.\nAsk the notary for the rest.",
+		created:    "2017-06-01T12:00:00Z",
+		secretFP:   secretFingerprint([]byte("saltsalt"), []byte("secret")),
+	}
+	for i := 1; i <= set.amount; i++ {
+		data := make([]byte, 64)
+		for j := range data {
+			data[j] = byte(i*37 + j*11)
+		}
+		share := createdShare{number: i, data: data, lines: enc.encode(data)}
+		switch i {
+		case 1:
+			share.holder = "Alice"
+		case 2:
+			share.label = "bank"
+		}
+		set.shares = append(set.shares, share)
+	}
+	return set
+}
+
+// The formats of the shares files must not change, other tools read them.
+func TestRenderGolden(t *testing.T) {
+	set := goldenSet(t)
+	for _, format := range []string{"text", "json", "csv", "armor", "compact"} {
+		g := testGsssa()
+		g.format = format
+		rendered, err := g.render(set)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		golden := filepath.Join("testdata", format+".golden")
+		if *update {
+			if err := ioutil.WriteFile(golden, []byte(rendered), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		want, err := ioutil.ReadFile(golden)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rendered != string(want) {
+			t.Errorf("the %s format is not the one of %s:\n%s", format, golden, rendered)
+		}
+
+		// And they must be read back to the same shares.
+		g.format = "auto"
+		parsed, err := g.parseSharesData(rendered)
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		if len(parsed.shares) != len(set.shares) {
+			t.Fatalf("%s: read %d shares, want %d", format, len(parsed.shares), len(set.shares))
+		}
+		for i, s := range parsed.shares {
+			if !bytes.Equal(s.data, set.shares[i].data) {
+				t.Errorf("%s: share %d is %x, want %x", format, i+1, s.data, set.shares[i].data)
+			}
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// pastedShares is what the shares of reveal --interactive are called in
+// messages, in place of a file name.
+const pastedShares = "the pasted shares"
+
+// readPastedShares asks for the shares one at a time, checks each one as it
+// is pasted so that only it has to be pasted again, and returns them as the
+// text of a shares file once enough of them are there.
+func (g *gsssa) readPastedShares() []byte {
+	if g.dictionary == "-" {
+		fmt.Fprintf(os.Stderr, "With --interactive the shares are pasted on stdin, so the dictionary can't be read from it.\n")
+		os.Exit(1)
+	}
+	in := bufio.NewReader(os.Stdin)
+
+	fmt.Fprintf(os.Stderr, "Paste the first line of the shares file, the one that starts with \"%s\", and press Enter. Press only Enter if there is none.\n", headerPrefix)
+	header, _ := readPastedLine(in)
+	if len(header) > 0 && !strings.HasPrefix(header, headerPrefix) {
+		fmt.Fprintf(os.Stderr, "That is not the first line of a shares file, the shares are read without it.\n")
+		header = ""
+	}
+	min := parseTextHeader(header).min
+
+	var blocks []string
+	words := 0
+	for {
+		fmt.Fprintf(os.Stderr, "Paste share %d and press Enter twice:\n", len(blocks)+1)
+		block, eof := readPastedBlock(in)
+		if len(block) > 0 {
+			share, problems := g.checkPastedShare(header, block, words)
+			if len(problems) > 0 {
+				fmt.Fprintf(os.Stderr, "This share can't be used:\n  %s\n", strings.Join(problems, "\n  "))
+				if eof {
+					break
+				}
+				fmt.Fprintf(os.Stderr, "Paste it again, the shares before it are kept.\n")
+				continue
+			}
+			if min == 0 {
+				min = share.min
+			}
+			words = share.words
+			blocks = append(blocks, block)
+		}
+		if eof {
+			break
+		}
+
+		entered := "1 share entered"
+		if len(blocks) != 1 {
+			entered = fmt.Sprintf("%d shares entered", len(blocks))
+		}
+		if min > 0 {
+			entered += fmt.Sprintf(", need at least %d", min)
+		}
+		if len(blocks) < min || len(blocks) < 2 {
+			fmt.Fprintf(os.Stderr, "%s.\n", entered)
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "%s - combine now? [y/N/add another] ", entered)
+		answer, eof := readPastedLine(in)
+		if answer = strings.ToLower(answer); answer == "y" || answer == "yes" || eof {
+			break
+		}
+	}
+
+	shares := strings.Join(blocks, "\n\n")
+	return []byte(fmt.Sprintf("%s\n\n%s\n\n%s%s\n", header, shares, fileChecksumPrefix, fileChecksum(shares, nil)))
+}
+
+// checkPastedShare reads the share in block, with the header line of its
+// file, and describes what is wrong with it. words is how many words the
+// shares before it have, 0 for the first share.
+func (g *gsssa) checkPastedShare(header, block string, words int) (parsedShare, []string) {
+	data := header + "\n\n" + block + "\n"
+	parsed, err := g.parseSharesData(data)
+	if len(g.dictionary) == 0 && len(g.lang) == 0 && (err != nil || len(parsed.unknownWords) > 0) {
+		parsed, err = g.detectDictionary(data, parsed, err)
+	}
+	if err != nil {
+		return parsedShare{}, []string{err.Error()}
+	}
+
+	problems := append([]string{}, parsed.malformed...)
+	for _, w := range parsed.wordCounts {
+		w.fileLine = 0
+		problems = append(problems, w.String())
+	}
+	for _, u := range parsed.unknownWords {
+		// The lines of the pasted text aren't the lines of the file.
+		u.fileLine = 0
+		if len(u.correction) == 0 && g.parseMode != "lenient" {
+			problems = append(problems, "not in the dictionary: "+u.String())
+		}
+	}
+	parsed.stripThreshold()
+	switch {
+	case len(problems) > 0:
+	case len(parsed.shares) == 0:
+		problems = append(problems, "there are no share words in it")
+	case len(parsed.shares) > 1:
+		problems = append(problems, "it is more than one share, paste them one at a time")
+	case words > 0 && parsed.shares[0].words != words:
+		problems = append(problems, fmt.Sprintf("it has %d words, but the shares before it have %d: some words are missing or too many", parsed.shares[0].words, words))
+	}
+	if len(problems) > 0 {
+		return parsedShare{}, problems
+	}
+	return parsed.shares[0], nil
+}
+
+// readPastedLine reads a line without the whitespace around it, and tells
+// whether the input ended.
+func readPastedLine(in *bufio.Reader) (string, bool) {
+	line, err := in.ReadString('\n')
+	return strings.TrimSpace(line), err == io.EOF
+}
+
+// readPastedBlock reads lines up to the first empty line after some text,
+// and tells whether the input ended.
+func readPastedBlock(in *bufio.Reader) (string, bool) {
+	var lines []string
+	for {
+		line, eof := readPastedLine(in)
+		if len(line) > 0 {
+			lines = append(lines, line)
+		}
+		if eof || (len(line) == 0 && len(lines) > 0) {
+			return strings.Join(lines, "\n"), eof
+		}
+	}
+}
@@ -2,23 +2,138 @@ package main
 
 import (
 	"bytes"
-	"encoding/base64"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	sssa "github.com/SSSaaS/sssa-golang"
+	"golang.org/x/term"
 	kingpin "gopkg.in/alecthomas/kingpin.v2"
 )
 
+// createOptions are the flags of create.
+type createOptions struct {
+	createMin           int
+	createAmount        int
+	showSHA256          bool
+	noChecksum          bool
+	allowWhitespaceOnly bool
+	strictDictionary    bool
+	encoding            string
+	wordsPerLine        int
+	groupSize           int
+	groupSeparator      string
+	lineChecksums       bool
+	embedThreshold      bool
+	withFingerprint     bool
+	noTimestamp         bool
+	created             time.Time
+	note                string
+	noteFile            string
+	split               bool
+	holderList          string
+	holders             []string
+	labelList           string
+	labels              []string
+	destList            string
+	dests               []string
+	filePattern         string
+	pdfDir              string
+	qrDir               string
+	qrOnly              bool
+	qrSize              int
+	qrLevel             string
+	qrTerminal          bool
+	noPrint             bool
+	forcePrint          bool
+	archive             string
+	keepFiles           bool
+	archived            []archivedFile // what was written, for --archive
+}
+
+// revealOptions are the flags of reveal.
+type revealOptions struct {
+	sharesFilenames []string // reveal can read several
+	argShares       []string
+	argData         *string // read instead of the file, for a --share
+	interactive     bool
+	shareGroup      int
+	useShares       string
+	outFormat       string
+	revealOut       string
+	revealRaw       bool
+	expectedSHA256  string
+	copyToClipboard bool
+	copyTimeout     time.Duration
+	show            bool
+	quiet           bool
+	forceText       bool
+}
+
+// readOptions are the flags of the commands that read a shares file.
+type readOptions struct {
+	sharesFilename     string
+	inputEncoding      string
+	parseMode          string
+	lenient            bool // --parse-mode lenient
+	strict             bool // --parse-mode strict
+	autoCorrect        bool
+	ignoreDictMismatch bool
+	forceParse         bool
+}
+
+// dictionaryOptions are the flags of the commands that use a dictionary.
+type dictionaryOptions struct {
+	dictionary       string
+	dictionaryFormat string
+	dictionarySHA256 string
+	lang             string
+	caseSensitive    bool
+	minPrefix        int
+	stdinDictionary  *string // the --dictionary read from stdin
+}
+
+// convertOptions are the flags of convert.
+type convertOptions struct {
+	toDictionary string
+	toLang       string
+	toFormat     string
+	convertOut   string
+}
+
+// generateOptions are the flags of dict generate.
+type generateOptions struct {
+	genInput  string
+	genOut    string
+	genCount  int
+	genMinLen int
+	genMaxLen int
+}
+
+// gsssa holds the flags of the command that is run, grouped by the commands
+// that have them.
 type gsssa struct {
-	createMin      int
-	createAmount   int
-	createSecret   string
-	sharesFilename string
+	createOptions
+	revealOptions
+	readOptions
+	dictionaryOptions
+	convertOptions
+	generateOptions
+
+	secret         secretSource
+	readsSecret    bool // the command reads a secret with g.secret
+	secretHex      bool
+	secretBase64   bool
+	format         string
+	section        string
+	dense          bool
 	forceOverwrite bool
-	dictionary     string
+	verbose        bool
 }
 
 var (
@@ -27,169 +142,1023 @@ var (
 	githash    = "None"
 )
 
-func (g *gsssa) getWordsFromDictionary() []string {
-
+// getWordsFromDictionary returns the words of --dictionary, or of the
+// built-in word list of --lang without it. It must have at least minWords
+// words, which depends on the encoding. Create and reveal both only get
+// words from here, cleaned up by dictionaryWords, so the words looked up are
+// exactly the ones that were written whatever whitespace the file has.
+func (g *gsssa) getWordsFromDictionary(minWords int) []string {
+	var data, origin string
 	if len(g.dictionary) > 0 {
-		wordsData, err := ioutil.ReadFile(g.dictionary)
+		data, origin = g.readDictionary(), "\""+g.dictionary+"\""
+	} else {
+		embedded, err := embeddedWords(g.language())
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		data, origin = embedded, fmt.Sprintf("the built-in word list \"%s\"", g.language())
+	}
+	g.checkDictionarySHA256(data, origin)
+
+	lines, err := wordColumn(dictionaryLines(data), g.dictionaryFormat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s can't be used as a dictionary: %s.\n", origin, err)
+		os.Exit(1)
+	}
+	failed := false
+	for _, p := range checkDictionary(lines, minWords) {
+		if p.blocking {
+			failed = true
+			fmt.Fprintf(os.Stderr, "%s can't be used as a dictionary: %s.\n", origin, p.message)
+		} else if g.verbose {
+			fmt.Fprintf(os.Stderr, "WARNING: %s: %s.\n", origin, p.message)
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+
+	words := dictionaryWords(lines)
+	if g.verbose {
+		fmt.Fprintf(os.Stderr, "Using %s as the dictionary (%d words).\n", origin, len(words))
+	}
+	return words
+}
+
+// readDictionary returns the contents of the --dictionary file, which is
+// read from stdin with "-". Stdin can only be read once, so it is kept for
+// the next call.
+func (g *gsssa) readDictionary() string {
+	if g.dictionary != "-" {
+		data, err := ioutil.ReadFile(g.dictionary)
 		if err != nil {
-			fmt.Printf("%+v\n", err)
+			fmt.Fprintf(os.Stderr, "%+v\n", err)
 			os.Exit(1)
 		}
+		return string(data)
+	}
 
-		words := strings.Split(string(wordsData), "\n")
-		if len(words) <= 255 {
-			fmt.Printf("\""+g.dictionary+"\" needs to have at least 256 words. It only has: %d\n", len(words))
+	if g.stdinDictionary == nil {
+		if source := g.secret.stdinSource(); g.readsSecret && len(source) > 0 {
+			fmt.Fprintf(os.Stderr, "The dictionary can't be read from stdin when %s reads the secret from it too.\n", source)
 			os.Exit(1)
 		}
-		return words
+		data, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%+v\n", err)
+			os.Exit(1)
+		}
+		text := string(data)
+		g.stdinDictionary = &text
+	}
+	return *g.stdinDictionary
+}
+
+// checkDictionarySHA256 exits if --dictionary-sha256 is given and isn't the
+// SHA-256 of data, the dictionary from origin exactly as it was read.
+func (g *gsssa) checkDictionarySHA256(data, origin string) {
+	if len(g.dictionarySHA256) == 0 {
+		return
+	}
+	sum := sha256.Sum256([]byte(data))
+	if actual := hex.EncodeToString(sum[:]); !strings.EqualFold(g.dictionarySHA256, actual) {
+		fmt.Fprintf(os.Stderr, "The SHA-256 of %s is %s, not the %s of --dictionary-sha256. Nothing was done.\n", origin, actual, g.dictionarySHA256)
+		os.Exit(1)
+	}
+}
+
+// language is the --lang of the built-in word list, or the one the shares
+// file names.
+func (g *gsssa) language() string {
+	if len(g.lang) == 0 {
+		return defaultLanguage
+	}
+	return g.lang
+}
+
+// recordedLanguage is the language written into shares files: only that of a
+// built-in word list other than the default one.
+func (g *gsssa) recordedLanguage() string {
+	if len(g.dictionary) > 0 || g.language() == defaultLanguage {
+		return ""
+	}
+	return g.language()
+}
+
+// useLanguage makes reveal use the built-in word list the shares file names,
+// unless a --dictionary is given.
+func (g *gsssa) useLanguage(lang string) {
+	if len(lang) > 0 && len(g.dictionary) == 0 {
+		g.lang = lang
+	}
+}
+
+// dictionaryName names the dictionary in use, for the shares file.
+func (g *gsssa) dictionaryName() string {
+	if len(g.dictionary) > 0 {
+		return filepath.Base(g.dictionary)
+	}
+	return "embedded"
+}
+
+// readNote returns the text of --note or --note-file.
+func (g *gsssa) readNote() string {
+	if len(g.note) > 0 && len(g.noteFile) > 0 {
+		fmt.Fprintf(os.Stderr, "Only one of --note and --note-file can be used.\n")
+		os.Exit(1)
+	}
+	if len(g.noteFile) == 0 {
+		return g.note
+	}
+
+	data, err := ioutil.ReadFile(g.noteFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%+v\n", err)
+		os.Exit(1)
+	}
+	return strings.TrimRight(strings.Replace(string(data), "\r\n", "\n", -1), "\n")
+}
+
+// stdioArgs turns "-f -", "-o -", "--out -" and "--dictionary -" into
+// "--file=-", "--out=-" and "--dictionary=-", as kingpin doesn't take "-" as
+// the value of a flag otherwise.
+func stdioArgs(args []string) []string {
+	var fixed []string
+	for i := 0; i < len(args); i++ {
+		if i+1 < len(args) && args[i+1] == "-" && (args[i] == "-f" || args[i] == "--file" || args[i] == "-o" || args[i] == "--out" || args[i] == "--dictionary") {
+			name := args[i]
+			switch name {
+			case "-f":
+				name = "--file"
+			case "-o":
+				name = "--out"
+			}
+			fixed = append(fixed, name+"=-")
+			i++
+			continue
+		}
+		fixed = append(fixed, args[i])
 	}
+	return fixed
+}
 
-	return embeddedWords
+// isTerminal reports whether f is connected to a terminal. Tests replace it.
+var isTerminal = func(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
 }
 
 func (g *gsssa) encrypt() {
 
 	if g.createMin > g.createAmount {
-		fmt.Printf("Minimum can't be higher than the amount of shares created.\n")
+		fmt.Fprintf(os.Stderr, "Minimum can't be higher than the amount of shares created.\n")
+		os.Exit(1)
+	}
+	if g.lineChecksums && (g.format != "text" || g.encoding == "raw") {
+		fmt.Fprintf(os.Stderr, "--line-checksums only works with the text format and not with the raw encoding.\n")
+		os.Exit(1)
+	}
+	if g.embedThreshold && (g.format != "text" && g.format != "json" || g.encoding == "raw" || g.createAmount > 255) {
+		fmt.Fprintf(os.Stderr, "--embed-threshold only works with the text and json formats, not with the raw encoding, and for at most 255 shares.\n")
+		os.Exit(1)
+	}
+	if g.dense {
+		if g.encoding != "words" || g.wordsPerLine > 0 {
+			fmt.Fprintf(os.Stderr, "--dense only works with the words encoding and without --words-per-line.\n")
+			os.Exit(1)
+		}
+		if bits := denseWordBits(g.getWordsFromDictionary(minDictionaryWords)); bits > 8 {
+			g.encoding = fmt.Sprintf("%s%d", packedWordsPrefix, bits)
+		}
+	}
+	if g.encoding == "bip39" && g.wordsPerLine > 0 {
+		fmt.Fprintf(os.Stderr, "--words-per-line doesn't work with the bip39 encoding, its lines are always the words of 32 bytes.\n")
+		os.Exit(1)
+	}
+	if g.encoding == "nibble" && g.wordsPerLine%2 != 0 {
+		fmt.Fprintf(os.Stderr, "The nibble encoding needs an even --words-per-line, as every byte is two words.\n")
+		os.Exit(1)
+	}
+	if g.withFingerprint && (g.split || len(g.holderList) > 0 || len(g.destList) > 0 || len(g.filePattern) > 0 || g.qrOnly) {
+		fmt.Fprintf(os.Stderr, "--fingerprint is only written into a shares file with all the shares, so it can't be used with --split, --holders, --dest, --file-pattern or --qr-only.\n")
+		os.Exit(1)
+	}
+	if g.wordsPerLine < 0 || g.groupSize < 0 {
+		fmt.Fprintf(os.Stderr, "--words-per-line and --group-size must be positive numbers.\n")
+		os.Exit(1)
+	}
+
+	g.created = time.Now()
+	g.parseHolders(g.holderList)
+	g.parseDests(g.destList)
+	g.parseLabels(g.labelList)
+	g.checkOutputFiles()
+
+	if g.secretHex && g.secretBase64 {
+		fmt.Fprintf(os.Stderr, "Only one of --secret-hex and --secret-base64 can be used.\n")
+		os.Exit(1)
+	}
+	if g.secret.generate > 0 && (g.secretHex || g.secretBase64) {
+		fmt.Fprintf(os.Stderr, "A generated secret is random bytes, it can't be combined with --secret-hex or --secret-base64.\n")
 		os.Exit(1)
 	}
 
-	if !g.forceOverwrite {
-		if _, err := os.Stat(g.sharesFilename); !os.IsNotExist(err) {
-			fmt.Printf("The shares file \"" + g.sharesFilename + "\" already exists. To force overwriting, use --force flag. This is done so a potential previous created shares file isn't overwritten by mistake.\n")
+	g.secret.messages = g.messages()
+	secret := g.secret.read()
+	if g.secretHex || g.secretBase64 {
+		decode := decodeSecretHex
+		if g.secretBase64 {
+			decode = decodeSecretBase64
+		}
+		decoded, err := decode(secret)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
+		secret = decoded
 	}
 
-	wordsDictionary := g.getWordsFromDictionary()
+	if err := validateSecret(secret, g.allowWhitespaceOnly); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 
-	combined, err := sssa.Create(g.createMin, g.createAmount, g.createSecret)
+	enc, err := g.shareEncoding(g.encoding)
 	if err != nil {
-		fmt.Println(err)
+		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
+	g.checkConfusable(enc)
+
+	if g.groupSize > 0 {
+		if err := checkGroupSeparator(g.groupSeparator, enc); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+
+	payload := secret
+	if !g.noChecksum {
+		payload = addChecksum(secret)
+	}
 
-	f, err := os.Create(g.sharesFilename)
+	combined, err := sssa.Create(g.createMin, g.createAmount, packSecret(payload))
 	if err != nil {
-		fmt.Println(err)
+		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
 
-	counter := 0
-	for _, c := range combined {
-		counter++
+	secretFP := ""
+	if g.withFingerprint {
+		secretFP, err = newFingerprint(secret)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
 
-		count := len(c) / 44
-		var buff bytes.Buffer
-		comment := fmt.Sprintf("# Share %d\n", counter)
-		fmt.Print(comment)
-		f.WriteString(comment)
+	set := &shareSet{
+		min:        g.createMin,
+		amount:     g.createAmount,
+		dictionary: g.dictionaryName(),
+		dictFP:     encodingFingerprint(enc),
+		encoding:   g.encoding,
+		checksum:   !g.noChecksum,
+		note:       g.readNote(),
+		lineCRC:    g.lineChecksums,
+		threshold:  g.embedThreshold,
+		created:    g.createdStamp(),
+		lang:       g.recordedLanguage(),
+		secretFP:   secretFP,
 
-		for j := 0; j < count; j++ {
-			part := c[j*44 : (j+1)*44]
-			bytedata, err := base64.URLEncoding.DecodeString(part)
+		groupSize:      g.groupSize,
+		groupSeparator: g.groupSeparator,
+		perLine:        g.wordsPerLine,
+	}
+	for i, c := range combined {
+		data, err := shareBytes(c)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if g.embedThreshold {
+			data = append([]byte{byte(g.createMin), byte(g.createAmount)}, data...)
+		}
+		share := createdShare{number: i + 1, data: data, lines: enc.encode(data)}
+		if g.wordsPerLine > 0 {
+			share.lines = rewrapLines(share.lines, g.wordsPerLine)
+		}
+		if g.lineChecksums {
+			share.lines, err = addLineChecksums(share.lines, enc)
 			if err != nil {
-				fmt.Println(err)
+				fmt.Fprintln(os.Stderr, err)
 				os.Exit(1)
 			}
-			buff.Write(bytedata)
-
-			tempString := ""
-			for _, b := range bytedata {
-				tempString += fmt.Sprintf("%s ", strings.TrimSpace(wordsDictionary[b]))
-			}
-			tempString = strings.TrimSpace(tempString) + "\n"
-			fmt.Print(tempString)
-			f.WriteString(tempString)
 		}
-		fmt.Println()
-		f.WriteString("\n")
+		if len(g.holders) > 0 {
+			share.holder = g.holders[i]
+		}
+		if len(g.labels) > 0 {
+			share.label = g.labels[i]
+		}
+		set.shares = append(set.shares, share)
 	}
 
-	comment := fmt.Sprintf("# You need %d shares out of these %d shares to be able to get your secret back.\n", g.createMin, g.createAmount)
-	fmt.Print(comment)
-	f.WriteString(comment)
+	g.writeShares(set)
+
+	if g.showSHA256 {
+		sum := sha256.Sum256(secret)
+		fmt.Fprintf(g.messages(), "SHA-256 of the secret: %s\nKeep it apart from the shares and check a reveal with --expected-sha256.\n\n", hex.EncodeToString(sum[:]))
+	}
+}
 
-	fmt.Printf("\n The file \"%s\" is now created with above shown information.\n\n", g.sharesFilename)
+// parseSharesData parses the contents of a shares file in the format of
+// --format.
+func (g *gsssa) parseSharesData(data string) (*sharesFile, error) {
+	var file *sharesFile
+	var err error
+	format := sharesFormat(g.format, data)
+	switch format {
+	case "json":
+		file, err = g.parseJSONShares(data)
+	case "csv":
+		file, err = g.parseCSVShares(data)
+	case "armor":
+		file, err = parseArmoredShares(data)
+	case "compact":
+		file, err = g.parseCompactShares(data)
+	default:
+		if parts := splitJoinedFiles(data); len(parts) > 1 {
+			file, err = g.parseJoinedShares(parts)
+		} else {
+			file, err = g.parseShares(data)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	file.format = format
+	return file, nil
+}
 
-	f.Close()
+// sharesFormat is the format of the shares file data, format unless that is
+// "auto".
+func sharesFormat(format, data string) string {
+	if format != "auto" {
+		return format
+	}
+	switch {
+	case isJSONShares(data):
+		return "json"
+	case isCSVShares(data):
+		return "csv"
+	case isArmoredShares(data):
+		return "armor"
+	case isCompactShares(data):
+		return "compact"
+	}
+	return "text"
 }
 
-func (g *gsssa) decrypt() {
+// stdinFile as --file reads the shares from stdin.
+const stdinFile = "-"
 
-	wordsDictionary := g.getWordsFromDictionary()
+// readStdinShares reads the shares from stdin until it ends, for the same
+// parsers as files.
+func (g *gsssa) readStdinShares() ([]byte, error) {
+	if g.dictionary == "-" {
+		fmt.Fprintf(os.Stderr, "The shares and the dictionary can't both be read from stdin.\n")
+		os.Exit(1)
+	}
+	if source := g.secret.stdinSource(); g.readsSecret && len(source) > 0 {
+		fmt.Fprintf(os.Stderr, "The shares can't be read from stdin when %s reads the secret from it too.\n", source)
+		os.Exit(1)
+	}
+	if isTerminal(os.Stdin) {
+		fmt.Fprintf(os.Stderr, "Paste the shares, then press Ctrl-D on an empty line to end (Ctrl-Z and Enter on Windows).\n")
+	}
+	return ioutil.ReadAll(os.Stdin)
+}
+
+// argumentShare turns the value of the n-th --share into the text of a
+// shares file. It is the words of one share, or a line of the compact
+// format.
+func argumentShare(n int, value string) (string, error) {
+	value = strings.TrimSpace(value)
+	fields := strings.Fields(value)
+	_, raw := rawShare(value)
+	switch {
+	case len(fields) == 0:
+		return "", fmt.Errorf("--share %d is empty, maybe because of stray quotes", n)
+	case strings.HasPrefix(value, compactPrefix):
+		return value + "\n", nil
+	case len(fields) == 1 && !raw:
+		return "", fmt.Errorf("--share %d is only \"%s\": put the words of a share in quotes, so that they are one argument", n, value)
+	}
+	var words []string
+	for _, f := range fields {
+		if f != defaultGroupSeparator {
+			words = append(words, f)
+		}
+	}
+	share := strings.Join(words, " ")
+	return fmt.Sprintf("%s\n\n%s%s\n", share, fileChecksumPrefix, fileChecksum(share, nil)), nil
+}
 
-	wordsMap := make(map[string]int)
-	for i, s := range wordsDictionary {
-		wordsMap[s] = i
+// readShares reads and parses the shares file of reveal and verify, and
+// prints the warnings about it.
+func (g *gsssa) readShares() *sharesFile {
+	g.applyParseMode()
+	var seedsData []byte
+	var err error
+	switch {
+	case g.argData != nil:
+		seedsData = []byte(*g.argData)
+	case g.interactive:
+		seedsData = g.readPastedShares()
+	case g.sharesFilename == stdinFile:
+		seedsData, err = g.readStdinShares()
+	default:
+		seedsData, err = readSharesFile(g.sharesFilename)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%+v\n", err)
+		os.Exit(1)
 	}
+	// Files saved on Windows end their lines with "\r\n", maybe only some
+	// of them.
+	seedsData = bytes.Replace(seedsData, []byte("\r\n"), []byte("\n"), -1)
+	seedsData = []byte(g.selectSection(string(seedsData)))
 
-	seedsData, err := ioutil.ReadFile(g.sharesFilename)
+	parsed, err := g.parseSharesData(string(seedsData))
+	if len(g.dictionary) == 0 && len(g.lang) == 0 && (err != nil || len(parsed.unknownWords) > 0) {
+		parsed, err = g.detectDictionary(string(seedsData), parsed, err)
+	}
 	if err != nil {
-		fmt.Printf("%+v\n", err)
+		fmt.Fprintf(os.Stderr, "Can't read the shares file \"%s\":\n%v\n", g.sharesFilename, err)
 		os.Exit(1)
 	}
 
-	seeds := strings.Split(string(seedsData), "\n")
+	var unknown []unknownWord
+	for _, u := range parsed.unknownWords {
+		if len(u.correction) > 0 {
+			fmt.Fprintf(os.Stderr, "Corrected \"%s\" to \"%s\" on %s.\n", u.word, u.correction, u.position())
+		} else {
+			unknown = append(unknown, u)
+		}
+	}
+	if len(unknown) > 0 {
+		described := ""
+		for i, u := range unknown {
+			if i == 10 {
+				described += fmt.Sprintf("  and %d more\n", len(unknown)-10)
+				break
+			}
+			described += "  " + u.String() + "\n"
+		}
+		if g.parseMode != "lenient" {
+			fmt.Fprintf(os.Stderr, "Words of \"%s\" that aren't in the dictionary:\n%sCheck them against the paper copy, or make sure the dictionary is the one the shares were created with. With --auto-correct words with only one dictionary word one letter away are read as that word. With --parse-mode lenient they are read as its first word and the shares are used anyway.\n", g.sharesFilename, described)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "WARNING: Words that aren't in the dictionary are read as its first word because of --parse-mode lenient, the secret is probably wrong:\n%s", described)
+	}
+
+	if len(parsed.wordCounts) > 0 {
+		described := ""
+		for _, w := range parsed.wordCounts {
+			described += "  " + w.String() + "\n"
+		}
+		fmt.Fprintf(os.Stderr, "WARNING: Lines of \"%s\" have words missing or too many, which moves all the words after them:\n%sCheck them against the paper copy, the secret is probably wrong otherwise.\n", g.sharesFilename, described)
+	}
+
+	warnings := parsed.stripThreshold()
+	g.skipMalformed(parsed)
+	// A --share has no share number.
+	if g.argData == nil {
+		warnings = append(warnings, parsed.unnumbered()...)
+	}
+	for _, w := range append(append(parsed.warnings, warnings...), parsed.dedupe()...) {
+		fmt.Fprintf(os.Stderr, "WARNING: %s\n", w)
+	}
+	return parsed
+}
+
+// skipMalformed goes on without the share blocks of the file that can't be
+// read if enough other shares are there, or exits. With --parse-mode strict
+// it always exits.
+func (g *gsssa) skipMalformed(parsed *sharesFile) {
+	if len(parsed.malformed) == 0 {
+		return
+	}
+	described := ""
+	for _, m := range parsed.malformed {
+		described += "  " + strings.Replace(m, "\n", "\n  ", -1) + "\n"
+	}
+	switch {
+	case g.parseMode == "strict":
+		fmt.Fprintf(os.Stderr, "Share blocks of \"%s\" that can't be read:\n%sBecause of --parse-mode strict they are not left out.\n", g.sharesFilename, described)
+		os.Exit(1)
+	case len(parsed.shares) == 0:
+		fmt.Fprintf(os.Stderr, "Share blocks of \"%s\" that can't be read:\n%sNo good shares are left.\n", g.sharesFilename, described)
+		os.Exit(1)
+	case len(parsed.shares) < parsed.min:
+		fmt.Fprintf(os.Stderr, "Share blocks of \"%s\" that can't be read:\n%sOnly %d good shares are left, but %d are needed.\n", g.sharesFilename, described, len(parsed.shares), parsed.min)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "WARNING: Left out the share blocks of \"%s\" that can't be read, %d good shares are left:\n%s", g.sharesFilename, len(parsed.shares), described)
+}
 
-	var shares []string
-	fullStr := ""
-	for _, s := range seeds {
+// readAllShares reads the shares files of reveal, each with readShares, and
+// puts their shares together. Shares that are in more than one file are
+// only used once.
+func (g *gsssa) readAllShares() *sharesFile {
+	if g.interactive {
+		g.sharesFilenames = []string{pastedShares}
+	} else if len(g.sharesFilenames) == 0 && len(g.argShares) == 0 {
+		g.sharesFilenames = []string{"shares.txt"}
+	}
+	collected, err := g.collectSharesFiles(g.sharesFilenames)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Can't find the shares files: %v.\n", err)
+		os.Exit(1)
+	}
+	// The --share values are read like files, under these names.
+	args := make(map[string]string)
+	for i, value := range g.argShares {
+		data, err := argumentShare(i+1, value)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Can't use the shares of --share: %v.\n", err)
+			os.Exit(1)
+		}
+		name := fmt.Sprintf("--share %d", i+1)
+		args[name] = data
+		collected = append(collected, name)
+	}
+	g.sharesFilenames = collected
+	if len(g.sharesFilenames) == 1 && len(args) == 0 {
+		g.sharesFilename = g.sharesFilenames[0]
+		return g.readShares()
+	}
 
-		if len(s) > 0 && s[0] == '#' {
-			fullStr = ""
+	var all *sharesFile
+	lang := g.lang
+	read := make(map[string]bool)
+	var filenames []string
+	for _, filename := range g.sharesFilenames {
+		if read[filename] {
+			fmt.Fprintf(os.Stderr, "NOTE: \"%s\" is given more than once, it is only read once.\n", filename)
 			continue
 		}
+		read[filename] = true
+		filenames = append(filenames, filename)
+		// Each file can be in another language.
+		g.sharesFilename, g.lang, g.argData = filename, lang, nil
+		if data, ok := args[filename]; ok {
+			g.argData = &data
+		}
+		parsed := g.readShares()
+		if g.argData != nil && parsed.format == "text" && !parsed.hasChecksum {
+			parsed.checksumUnknown = true
+		}
+		for _, s := range parsed.shares {
+			s.filename = filename
+			if s.from == nil {
+				s.from = parsed
+			}
+			if all == nil {
+				all = &sharesFile{}
+				*all = *parsed
+				all.shares = nil
+			}
+			all.shares = append(all.shares, s)
+		}
+		if all != nil && all.min == 0 {
+			all.min, all.amount = parsed.min, parsed.amount
+		}
+		if all != nil && all.checksumUnknown && parsed.hasChecksum {
+			all.hasChecksum, all.checksumUnknown = true, false
+		}
+	}
+	g.sharesFilenames = filenames
+	if all == nil {
+		all = &sharesFile{}
+	}
+	for _, w := range all.dedupe() {
+		fmt.Fprintf(os.Stderr, "NOTE: %s\n", w)
+	}
+	return all
+}
+
+// revealFiles names the shares files of reveal in messages.
+func (g *gsssa) revealFiles() string {
+	return "\"" + strings.Join(g.sharesFilenames, "\", \"") + "\""
+}
+
+// verify checks the shares file without revealing the secret.
+func (g *gsssa) verify() {
+	parsed := g.readShares()
+	fmt.Print(parsed.report(g.sharesFilename))
+	if len(parsed.created) > 0 {
+		fmt.Printf("The shares were created at %s.\n", parsed.created)
+	}
+	if len(parsed.malformed) > 0 {
+		fmt.Printf("%d share blocks of the file can't be read.\n", len(parsed.malformed))
+		os.Exit(1)
+	}
+	if len(parsed.wordCounts) > 0 {
+		fmt.Printf("%d lines of the file have words missing or too many.\n", len(parsed.wordCounts))
+		os.Exit(1)
+	}
+	if missing := parsed.tooFewShares(); len(missing) > 0 {
+		fmt.Fprintf(os.Stderr, "WARNING: %s\n", missing)
+	}
+	fmt.Println("The shares file is OK.")
+}
+
+// createdStamp is when the shares were created, as it goes into the shares
+// file, or empty with --no-timestamp.
+func (g *gsssa) createdStamp() string {
+	if g.noTimestamp {
+		return ""
+	}
+	return g.created.UTC().Format(time.RFC3339)
+}
+
+// fingerprint shows the fingerprint of the secret in the shares file, and
+// with a secret given checks whether it is the secret of the file.
+func (g *gsssa) fingerprint() {
+	parsed := g.readShares()
+	if len(parsed.fingerprint) == 0 {
+		fmt.Fprintf(os.Stderr, "The shares file \"%s\" has no fingerprint of its secret.\n", g.sharesFilename)
+		os.Exit(1)
+	}
+	fmt.Printf("Fingerprint of the shares file: %s\n", parsed.fingerprint)
+	if len(g.secret.chosen()) == 0 {
+		return
+	}
+
+	g.secret.messages = os.Stderr
+	match, computed, err := matchFingerprint(parsed.fingerprint, g.secret.read())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Printf("Fingerprint of the given secret: %s\n", computed)
+	if !match {
+		fmt.Printf("MISMATCH: the given secret is not the secret of the shares file.\n")
+		os.Exit(1)
+	}
+	fmt.Printf("match: the given secret is the secret of the shares file.\n")
+}
+
+func (g *gsssa) decrypt() {
+	parsed := g.readAllShares()
+	g.chooseGroup(parsed)
+	if len(g.useShares) > 0 {
+		if err := parsed.keepShares(g.useShares); err != nil {
+			fmt.Fprintf(os.Stderr, "Can't use only the shares %s: %v.\n", g.useShares, err)
+			os.Exit(1)
+		}
+	}
+	if len(parsed.shares) == 0 {
+		fmt.Fprintf(os.Stderr, "No shares found in %s.\n", g.revealFiles())
+		os.Exit(1)
+	}
+	// Combining too few shares gives random bytes instead of an error.
+	if missing := parsed.tooFewShares(); len(missing) > 0 {
+		fmt.Fprintln(os.Stderr, missing)
+		os.Exit(1)
+	}
+	if len(parsed.shares) == 1 {
+		fmt.Fprintf(os.Stderr, "WARNING: Only one share was found, but one share is never enough to reveal a secret.\n")
+	}
+
+	res, err := sssa.Combine(parsed.shareStrings())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 
-		if len(s) == 0 {
-			if len(fullStr) > 0 {
-				shares = append(shares, fullStr)
+	secret := unpackSecret(res)
+	if parsed.checksumUnknown {
+		// Shares are created with a checksum unless --no-checksum is given,
+		// and random bytes almost never end with a matching one.
+		if checked, err := verifyChecksum(secret); err == nil {
+			secret = checked
+		} else {
+			fmt.Fprintf(os.Stderr, "WARNING: The secret of the --share shares doesn't end with a checksum. Unless they were created with --no-checksum, some of them are wrong or of another secret.\n")
+		}
+	}
+	if parsed.hasChecksum {
+		checked, err := verifyChecksum(secret)
+		if err != nil {
+			if subset, s := consistentShares(parsed.shares, parsed.min); subset != nil {
+				fmt.Fprintf(os.Stderr, "WARNING: The checksum of the secret only matches without some of the shares, they are probably of a different secret or wrong. Only these are used: %s.\n", shareGroup{shares: subset}.names())
+				parsed.shares, checked, err = subset, s, nil
 			}
-			fullStr = ""
-			continue
 		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		secret = checked
+	}
+
+	fmt.Fprint(os.Stderr, parsed.report(g.sharesFilenames...))
 
-		seedWords := strings.Split(s, " ")
-		var buff bytes.Buffer
-		for _, w := range seedWords {
-			buff.WriteByte(byte(wordsMap[w]))
+	if len(parsed.fingerprint) > 0 {
+		match, _, err := matchFingerprint(parsed.fingerprint, secret)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if !match {
+			fmt.Fprintf(os.Stderr, "The revealed secret doesn't have the fingerprint of the shares file. Some shares are probably wrong, mixed up or too few.\n")
+			os.Exit(1)
 		}
+	}
 
-		fullStr += base64.URLEncoding.EncodeToString(buff.Bytes())
+	if len(g.expectedSHA256) > 0 {
+		sum := sha256.Sum256(secret)
+		if !strings.EqualFold(hex.EncodeToString(sum[:]), strings.TrimSpace(g.expectedSHA256)) {
+			fmt.Fprintf(os.Stderr, "MISMATCH: the revealed secret does not have the expected SHA-256.\n")
+			os.Exit(1)
+		}
+		fmt.Printf("match: the revealed secret has the expected SHA-256.\n")
+		return
 	}
 
-	res, err := sssa.Combine(shares)
-	if err != nil {
-		fmt.Println(err)
+	// --secret-hex and --secret-base64 are shorthands for --out-format.
+	if g.secretHex || g.secretBase64 {
+		format := "hex"
+		if g.secretBase64 {
+			format = "base64"
+		}
+		if (g.secretHex && g.secretBase64) || (g.outFormat != "plain" && g.outFormat != format) {
+			fmt.Fprintf(os.Stderr, "Only one output format can be chosen.\n")
+			os.Exit(1)
+		}
+		g.outFormat = format
+	}
+	if g.revealRaw && g.outFormat != "plain" {
+		fmt.Fprintf(os.Stderr, "--raw can't be combined with another output format than plain.\n")
 		os.Exit(1)
 	}
 
-	fmt.Printf("RESULT: %s\n", res)
+	if len(g.revealOut) > 0 && g.revealOut != "-" {
+		if err := writeSecretFile(g.revealOut, g.encodeSecret(secret, true), g.forceOverwrite); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "The secret was written to \"%s\".\n", g.revealOut)
+		return
+	}
+
+	if g.copyToClipboard {
+		g.copySecret(g.encodeSecret(secret, false))
+		return
+	}
+
+	// Don't put the secret on a screen someone else might be looking at
+	// unless it was asked for.
+	if isTerminal(os.Stdout) && !g.show {
+		// With --quiet stdout only ever gets the secret.
+		out := os.Stdout
+		if g.quiet {
+			out = os.Stderr
+		}
+		fmt.Fprintf(out, "Secret recovered (%d bytes). Pass --show to display it.\n", len(secret))
+		return
+	}
+
+	if g.revealRaw {
+		os.Stdout.Write(secret)
+		return
+	}
+
+	// Binary bytes can mess up the terminal, with --quiet as well.
+	if g.outFormat == "plain" && !g.forceText && !isPrintableText(secret) {
+		fmt.Fprintf(os.Stderr, "WARNING: The result is not text. This often means wrong or too few shares were used. Showing a hex dump instead, use --force-text to show it as it is.\n")
+		fmt.Print(hex.Dump(secret))
+		return
+	}
+
+	if g.quiet {
+		os.Stdout.Write(g.encodeSecret(secret, false))
+		fmt.Println()
+		return
+	}
+
+	printSecret(g.encodeSecret(secret, false))
+}
+
+// The flags that several commands have are added by these, so they are the
+// same in all of them.
+
+// dictionaryFlags adds --dictionary, with help, and the flags about how it
+// is read to cmd.
+func (g *gsssa) dictionaryFlags(cmd *kingpin.CmdClause, help string) *kingpin.FlagClause {
+	flag := cmd.Flag("dictionary", help)
+	flag.StringVar(&g.dictionary)
+	cmd.Flag("dictionary-format", "How the --dictionary file has its words: plain with one word per line, numbered with a number before every word like the EFF lists, or auto to find out from its first lines.").Default("auto").EnumVar(&g.dictionaryFormat, "auto", "plain", "numbered")
+	cmd.Flag("dictionary-sha256", "Only go on if the SHA-256 of the --dictionary file, or of the built-in word list without it, is this hex string. This is not the dictionary-fingerprint in shares files, which only covers the words that are used.").StringVar(&g.dictionarySHA256)
+	return flag
+}
+
+// wordFlags adds the flags about which words of a shares file are read as
+// dictionary words to cmd.
+func (g *gsssa) wordFlags(cmd *kingpin.CmdClause) {
+	cmd.Flag("case-sensitive", "Only accept words in the case they have in the dictionary, for dictionaries with words that only differ in case.").BoolVar(&g.caseSensitive)
+	cmd.Flag("min-prefix", "Accept the start of a word instead of the whole word when it has at least this many letters and only one word starts with it. 0 to only accept whole words.").Default("4").IntVar(&g.minPrefix)
+}
+
+// sharesFormatFlags adds the flags about how the shares file is written to
+// cmd.
+func (g *gsssa) sharesFormatFlags(cmd *kingpin.CmdClause) {
+	cmd.Flag("format", "Format of the shares file: text, json, csv, armor, compact, or auto to detect it.").Default("auto").EnumVar(&g.format, "auto", "text", "json", "csv", "armor", "compact")
+	cmd.Flag("input-encoding", "How the share lines of a text shares file are written: words, base64 for shares as sssa writes them, or auto for both.").Default("auto").EnumVar(&g.inputEncoding, "auto", "words", "base64")
+}
+
+// parseModeFlags adds --parse-mode to cmd, with mode as its default, with
+// --strict and --lenient for its modes and --auto-correct.
+func (g *gsssa) parseModeFlags(cmd *kingpin.CmdClause, mode string) {
+	cmd.Flag("parse-mode", "How exactly the shares must be written. strict: only as create wrote them, and no share block or word is ever left out. normal: also words in another case or only their start, moved line breaks and extra whitespace, and share blocks that can't be read are left out when enough other shares are there. lenient: also words that aren't in the dictionary are read as its first word, only the words encoding can do this. verify is strict by default, so that problems are found while the shares can still be written again, reveal and convert are normal.").Default(mode).EnumVar(&g.parseMode, "strict", "normal", "lenient")
+	cmd.Flag("strict", "Same as --parse-mode strict.").BoolVar(&g.strict)
+	cmd.Flag("lenient", "Same as --parse-mode lenient.").BoolVar(&g.lenient)
+	cmd.Flag("auto-correct", "Read a word that isn't in the dictionary as the dictionary word one letter away from it, when there is only one, and tell which words were corrected.").BoolVar(&g.autoCorrect)
 }
 
 func main() {
 	g := new(gsssa)
 
+	app.Flag("verbose", "Tell more about what is done, like which dictionary is used.").BoolVar(&g.verbose)
+
 	create := app.Command("create", "Create new Shamir's Secret Sharing strings.").Action(func(c *kingpin.ParseContext) error {
+		g.readsSecret = true
 		g.encrypt()
 		return nil
 	})
 	create.Flag("min", "Minimum shares that are needed.").Default("2").IntVar(&g.createMin)
 	create.Flag("amount", "Amount of shares to generate.").Default("3").IntVar(&g.createAmount)
-	create.Flag("dictionary", "The word list file. Should have at least 256 words in it. Separated by a newline. (Currently only the first 256 ones are used.)").StringVar(&g.dictionary)
-	create.Flag("file", "Filename of the file containing the shares.").Short('f').Default("shares.txt").StringVar(&g.sharesFilename)
+	g.dictionaryFlags(create, "The word list file. Should have at least 256 words in it. Separated by a newline. (Currently only the first 256 ones are used.) With - it is read from stdin.")
+	create.Flag("strict-dictionary", "Don't create shares when dictionary words in use differ in only one letter or two swapped letters, instead of warning about them.").BoolVar(&g.strictDictionary)
+	create.Flag("lang", "The language of the built-in word list to use without --dictionary: "+strings.Join(languageCodes(), ", ")+". Reveal finds it in the shares file.").Default(defaultLanguage).EnumVar(&g.lang, languageCodes()...)
+	create.Flag("case-sensitive", "Allow a dictionary with words that only differ in case. Reveal then needs --case-sensitive as well.").BoolVar(&g.caseSensitive)
+	create.Flag("file", "Filename of the file containing the shares. With - the shares are only written to stdout.").Short('f').Default("shares.txt").StringVar(&g.sharesFilename)
 	create.Flag("force", "Overwrite file with shares.").BoolVar(&g.forceOverwrite)
-	create.Arg("secret", "The secret string to hide.").Required().StringVar(&g.createSecret)
+	create.Flag("split", "Write every share to its own file, share-1.txt, share-2.txt and so on, in the directory of --file.").BoolVar(&g.split)
+	create.Flag("holders", "Comma separated names of the share holders. Every holder gets their own file, share-<name>.txt, next to --file.").StringVar(&g.holderList)
+	create.Flag("labels", "Comma separated labels of the shares, written with them in the file, e.g. \"alice,bob,,dave\" to leave the third share without one.").StringVar(&g.labelList)
+	create.Flag("dest", "Comma separated directories, one per share, e.g. the mount points of USB sticks. Share 1 is only written to the first one, share 2 only to the second and so on, as share-<n>.txt.").StringVar(&g.destList)
+	create.Flag("file-pattern", "Write every share to its own file named by this pattern, e.g. \"backup/{date}/share-{n}-of-{amount}.txt\". Placeholders are {n}, {label} (holder name or number), {amount}, {min} and {date}. Directories are created as needed.").StringVar(&g.filePattern)
+	create.Flag("pdf-dir", "Also write a printable PDF sheet of every share into this directory, with fields for the holder name and the date.").StringVar(&g.pdfDir)
+	create.Flag("qr-dir", "Also write a PNG QR code of every share into this directory. A QR code holds its share as a complete shares file in text format, so a scan saved to a file can be revealed as it is.").StringVar(&g.qrDir)
+	create.Flag("qr-only", "Only write the QR codes of --qr-dir, no shares file.").BoolVar(&g.qrOnly)
+	create.Flag("section", "Add the shares as a section with this name to the end of the shares file, so one file can hold the shares of several secrets.").StringVar(&g.section)
+	create.Flag("embed-threshold", "Start every share with two more words that say how many shares are needed out of how many, so a share on its own still tells. Only for the text and json formats.").BoolVar(&g.embedThreshold)
+	create.Flag("fingerprint", "Write a salted fingerprint of the secret into the shares file, for gsssa fingerprint to check a secret against without combining the shares. Anyone with the file can check guesses of the secret with it, so only use it for secrets that can't be guessed, like generated ones. It is never written into the files or QR codes of single shares.").BoolVar(&g.withFingerprint)
+	create.Flag("no-timestamp", "Don't write when the shares were created into the shares file.").BoolVar(&g.noTimestamp)
+	create.Flag("line-checksums", "End every line with one more word, a checksum of the line, so reveal can tell which line was copied wrong. Only for the text format.").BoolVar(&g.lineChecksums)
+	create.Flag("no-print", "Don't show the shares, only write them to the file. This is the default when stdout is not a terminal.").BoolVar(&g.noPrint)
+	create.Flag("print", "Show the shares even when stdout is not a terminal.").BoolVar(&g.forcePrint)
+	create.Flag("archive", "Pack all files written, the shares files, QR codes and PDF sheets, into this tar.gz archive and remove them. Reveal from it with -f archive.tar.gz:share-1.txt.").StringVar(&g.archive)
+	create.Flag("keep-files", "Keep the files packed into --archive.").BoolVar(&g.keepFiles)
+	create.Flag("qr-terminal", "Print a QR code of every share after its words, when stdout is a terminal. Large shares are split over several numbered QR codes.").BoolVar(&g.qrTerminal)
+	create.Flag("qr-size", "Width and height of the QR code images in pixels.").Default("512").IntVar(&g.qrSize)
+	create.Flag("qr-level", "Error correction level of the QR codes: low, medium, high or highest.").Default("medium").EnumVar(&g.qrLevel, "low", "medium", "high", "highest")
+	create.Flag("encoding", "How the shares are written: as dictionary words, raw as the base64 strings of the secret sharing library, as hex, as base58, as decimal numbers, as NATO alphabet words, as the dice indices of a diceware list given with --dictionary, or as words of the BIP-39 English word list given with --dictionary, 11 bits each, or as two words per byte of only the first 16 dictionary words with nibble. Only words, dice, bip39 and nibble need a dictionary.").Default("words").EnumVar(&g.encoding, "words", "raw", "hex", "base58", "decimal", "nato", "dice", "bip39", "nibble")
+	create.Flag("dense", "With a --dictionary of 512 words or more, let every word stand for more than 8 bits so the shares need fewer words: 9 bits for 512 words up to 16 bits for 65536. Only the largest power of two of words is used.").BoolVar(&g.dense)
+	create.Flag("words-per-line", "Put this many words on a line instead of 32 so the lines don't wrap when printed.").IntVar(&g.wordsPerLine)
+	create.Flag("group-size", "Put a separator between every this many words of a line in the text format, to make copying them easier.").IntVar(&g.groupSize)
+	create.Flag("group-separator", "The separator of --group-size.").Default(defaultGroupSeparator).StringVar(&g.groupSeparator)
+	create.Flag("note", "Instructions for whoever gets the shares, written as comments at the top of every shares file.").StringVar(&g.note)
+	create.Flag("note-file", "Read the --note from this file.").StringVar(&g.noteFile)
+	create.Flag("format", "Format of the shares file: text, json or csv for other tools to read, armor for blocks that survive being pasted into emails and tickets, or compact for one line per share.").Default("text").EnumVar(&g.format, "text", "json", "csv", "armor", "compact")
+	create.Flag("no-checksum", "Don't add a checksum of the secret to the shares. Without it a wrong combination of shares can't be detected when revealing.").BoolVar(&g.noChecksum)
+	create.Flag("show-sha256", "Show the SHA-256 of the secret, to check a reveal against later with --expected-sha256.").BoolVar(&g.showSHA256)
+	create.Flag("allow-whitespace-only", "Allow a secret that only consists of whitespace.").BoolVar(&g.allowWhitespaceOnly)
+	create.Flag("secret-hex", "The secret is hex encoded and is decoded before it is split.").BoolVar(&g.secretHex)
+	create.Flag("secret-base64", "The secret is base64 encoded (standard or URL safe) and is decoded before it is split.").BoolVar(&g.secretBase64)
+
+	// Where the secret comes from. Without any of these the secret is asked
+	// for on the terminal.
+	create.Flag("secret-stdin", "Read the secret from stdin until EOF. One trailing newline is removed unless --keep-trailing-newline is given.").BoolVar(&g.secret.stdin)
+	create.Flag("secret-file", "Read the secret from this file. The contents are used as they are unless --strip-trailing-newline is given.").StringVar(&g.secret.file)
+	create.Flag("secret-env", "Read the secret from the environment variable with this name. The variable is removed from the environment once read.").StringVar(&g.secret.env)
+	create.Flag("secret-cmd", "Run this command and use its output as the secret. One trailing newline is removed unless --keep-trailing-newline is given.").StringVar(&g.secret.cmd)
+	create.Flag("secret-json", "Use the string field at a path in a JSON file as the secret, e.g. \"state.json:.outputs.root_password.value\".").StringVar(&g.secret.json)
+	create.Flag("secret-yaml", "Use the string field at a path in a YAML file as the secret, e.g. \"secret.yaml:.data.password\".").StringVar(&g.secret.yaml)
+	create.Flag("generate", "Generate a random secret of this many bytes and split it. The secret is shown once in hex and base64.").IntVar(&g.secret.generate)
+	create.Flag("keep-trailing-newline", "Keep a trailing newline of a secret read from stdin or a command.").BoolVar(&g.secret.keepNewline)
+	create.Flag("strip-trailing-newline", "Remove one trailing newline from a secret read from a file.").BoolVar(&g.secret.stripNewline)
+	create.Flag("no-confirm", "Don't ask a second time when the secret is entered at the prompt.").BoolVar(&g.secret.noConfirm)
+	create.Flag("i-know-argv-is-visible", "Don't warn when the secret is given as an argument.").BoolVar(&g.secret.argVisibleOK)
+	create.Arg("secret", "Deprecated: the secret string to hide. Arguments can be seen by other users and end up in the shell history, use the prompt or one of the --secret-* flags instead.").StringVar(&g.secret.arg)
 
 	reveal := app.Command("reveal", "Reveal secret from shares.").Action(func(c *kingpin.ParseContext) error {
 		g.decrypt()
 		return nil
 	})
 
-	reveal.Flag("dictionary", "The word list file. Should have at least 256 words in it. Separated by a newline. Make sure this is the same wordlist used when created the shares. (Currently only the first 256 ones are used.)").StringVar(&g.dictionary)
-	reveal.Flag("file", "Filename of the file containing the shares.").Short('f').Default("shares.txt").StringVar(&g.sharesFilename)
+	g.dictionaryFlags(reveal, "The word list file. Should have at least 256 words in it. Separated by a newline. Make sure this is the same wordlist used when created the shares. (Currently only the first 256 ones are used.) With - it is read from stdin.")
+	g.wordFlags(reveal)
+	reveal.Flag("group", "Which of the secrets of a file with shares of several to reveal, from 1 in the order of the file. Without it the secret with the most shares is revealed.").PlaceHolder("N").IntVar(&g.shareGroup)
+	reveal.Flag("interactive", "Paste the shares one at a time instead of reading them from --file. Each one is checked right away, so only a wrong one has to be pasted again.").BoolVar(&g.interactive)
+	reveal.Flag("use", "Reveal with only the shares with these numbers, like 1,4, to try out a part of the shares.").PlaceHolder("N,N").StringVar(&g.useShares)
+	reveal.Flag("file", "Filename of the file containing the shares, or - for stdin, shares.txt without it and --share. Can be given more than once, like for the files of single shares, and be a directory or a pattern like 'share-*.txt' for the shares files in it.").Short('f').PlaceHolder("shares.txt").StringsVar(&g.sharesFilenames)
+	reveal.Flag("share", "A share to use: its words, in quotes and without line checksums, or its line of the compact format. Can be given more than once, and with --file.").PlaceHolder("\"WORDS\"").StringsVar(&g.argShares)
+	reveal.Flag("ignore-dictionary-mismatch", "Reveal even when the shares file says it was created with another dictionary. The secret will most likely be wrong.").BoolVar(&g.ignoreDictMismatch)
+	reveal.Flag("force-parse", "UNSAFE: read a shares file of a newer gsssa version as well as possible, even though it can give a wrong secret.").BoolVar(&g.forceParse)
+	reveal.Flag("section", "Reveal the secret of this section of the shares file.").StringVar(&g.section)
+	g.sharesFormatFlags(reveal)
+	g.parseModeFlags(reveal, "normal")
+	reveal.Flag("out-format", "How to show the revealed secret: as it is (plain), as lowercase hex or as standard base64.").Default("plain").EnumVar(&g.outFormat, "plain", "hex", "base64")
+	reveal.Flag("secret-hex", "Same as --out-format=hex.").BoolVar(&g.secretHex)
+	reveal.Flag("secret-base64", "Same as --out-format=base64.").BoolVar(&g.secretBase64)
+	reveal.Flag("out", "Write the revealed secret to this file, readable only by you, instead of showing it. Use - for stdout.").StringVar(&g.revealOut)
+	reveal.Flag("force", "Overwrite the --out file if it exists.").BoolVar(&g.forceOverwrite)
+	reveal.Flag("expected-sha256", "Only check that the revealed secret has this SHA-256 (hex) instead of showing it. Exits non-zero on a mismatch.").StringVar(&g.expectedSHA256)
+	reveal.Flag("copy", "Copy the secret to the clipboard instead of showing it, and clear the clipboard again after --copy-timeout.").BoolVar(&g.copyToClipboard)
+	reveal.Flag("copy-timeout", "How long the secret stays on the clipboard with --copy.").Default("45s").DurationVar(&g.copyTimeout)
+	reveal.Flag("show", "Show the secret even when stdout is a terminal.").BoolVar(&g.show)
+	reveal.Flag("quiet", "Only print the secret followed by a newline, without \"RESULT:\". Everything else goes to stderr.").Short('q').BoolVar(&g.quiet)
+	reveal.Flag("force-text", "Show the secret as it is even when it doesn't look like text.").BoolVar(&g.forceText)
+	reveal.Flag("raw", "Write exactly the bytes of the secret to stdout, without \"RESULT:\" or a newline.").BoolVar(&g.revealRaw)
+
+	verify := app.Command("verify", "Check a shares file, without revealing the secret.").Action(func(c *kingpin.ParseContext) error {
+		g.verify()
+		return nil
+	})
+
+	g.dictionaryFlags(verify, "The word list file the shares were created with.")
+	g.wordFlags(verify)
+	verify.Flag("file", "Filename of the file containing the shares, or - for stdin.").Short('f').Default("shares.txt").StringVar(&g.sharesFilename)
+	verify.Flag("ignore-dictionary-mismatch", "Check the file even when it says it was created with another dictionary.").BoolVar(&g.ignoreDictMismatch)
+	verify.Flag("force-parse", "UNSAFE: read a shares file of a newer gsssa version as well as possible.").BoolVar(&g.forceParse)
+	verify.Flag("section", "Check this section of the shares file.").StringVar(&g.section)
+	g.sharesFormatFlags(verify)
+	g.parseModeFlags(verify, "strict")
+
+	fingerprint := app.Command("fingerprint", "Show the fingerprint of the secret in a shares file, or check a secret against it.").Action(func(c *kingpin.ParseContext) error {
+		g.readsSecret = true
+		g.fingerprint()
+		return nil
+	})
+
+	g.dictionaryFlags(fingerprint, "The word list file the shares were created with.")
+	g.wordFlags(fingerprint)
+	fingerprint.Flag("file", "Filename of the file containing the shares, or - for stdin.").Short('f').Default("shares.txt").StringVar(&g.sharesFilename)
+	fingerprint.Flag("section", "Use this section of the shares file.").StringVar(&g.section)
+	fingerprint.Flag("format", "Format of the shares file: text, json, or auto to detect it.").Default("auto").EnumVar(&g.format, "auto", "text", "json")
+	fingerprint.Flag("secret", "Check this secret. Arguments can be seen by other users, prefer the other --secret-* flags.").StringVar(&g.secret.arg)
+	fingerprint.Flag("secret-stdin", "Check the secret read from stdin until EOF.").BoolVar(&g.secret.stdin)
+	fingerprint.Flag("secret-file", "Check the secret in this file.").StringVar(&g.secret.file)
+	fingerprint.Flag("secret-env", "Check the secret in the environment variable with this name.").StringVar(&g.secret.env)
+
+	convert := app.Command("convert", "Write the shares of a shares file with another dictionary, without revealing the secret. Any number of shares can be converted.").Action(func(c *kingpin.ParseContext) error {
+		g.convert()
+		return nil
+	})
+	convert.Flag("file", "Filename of the file containing the shares, or - for stdin.").Short('f').Default("shares.txt").StringVar(&g.sharesFilename)
+	g.dictionaryFlags(convert, "The word list file the shares were created with. With - it is read from stdin.")
+	convert.Flag("to-dictionary", "The word list file to write the shares with. Without it the built-in word list of --to-lang is used.").StringVar(&g.toDictionary)
+	convert.Flag("to-lang", "The language of the built-in word list to write the shares with without --to-dictionary: "+strings.Join(languageCodes(), ", ")+".").Default(defaultLanguage).EnumVar(&g.toLang, languageCodes()...)
+	convert.Flag("out", "Filename of the converted shares file. Use - for stdout.").Short('o').Required().StringVar(&g.convertOut)
+	convert.Flag("force", "Overwrite the --out file if it exists.").BoolVar(&g.forceOverwrite)
+	g.sharesFormatFlags(convert)
+	convert.Flag("to-format", "Format of the converted shares file, the one of the shares file if not given.").EnumVar(&g.toFormat, "text", "json", "csv", "armor", "compact")
+	convert.Flag("section", "Convert this section of the shares file.").StringVar(&g.section)
+	g.wordFlags(convert)
+	g.parseModeFlags(convert, "normal")
+	convert.Flag("ignore-dictionary-mismatch", "Convert the file even when it says it was created with another dictionary.").BoolVar(&g.ignoreDictMismatch)
+
+	dict := app.Command("dict", "Work with word lists for the words encoding.")
+	dictCheck := dict.Command("check", "Check a word list for problems before using it as a dictionary.").Action(func(c *kingpin.ParseContext) error {
+		g.dictCheck()
+		return nil
+	})
+	g.dictionaryFlags(dictCheck, "The word list file to check, one word per line.").Required()
+	dictGenerate := dict.Command("generate", "Make a word list of the most frequent words of a text.").Action(func(c *kingpin.ParseContext) error {
+		g.dictGenerate()
+		return nil
+	})
+	dictGenerate.Flag("input", "The text to take the words from.").Required().StringVar(&g.genInput)
+	dictGenerate.Flag("count", "How many words the list gets.").Default("256").IntVar(&g.genCount)
+	dictGenerate.Flag("min-len", "Leave out words with fewer letters.").Default("4").IntVar(&g.genMinLen)
+	dictGenerate.Flag("max-len", "Leave out words with more letters.").Default("8").IntVar(&g.genMaxLen)
+	dictGenerate.Flag("out", "Write the word list to this file instead of stdout.").StringVar(&g.genOut)
+	dictGenerate.Flag("force", "Overwrite the --out file if it exists.").BoolVar(&g.forceOverwrite)
+
+	words := app.Command("words", "Show the words the words encoding uses, by the value they stand for, and the dictionary fingerprint shares files made with them have.").Action(func(c *kingpin.ParseContext) error {
+		g.wordTable()
+		return nil
+	})
+	g.dictionaryFlags(words, "The word list file. Without it the built-in word list of --lang is used.")
+	words.Flag("lang", "The language of the built-in word list to use without --dictionary: "+strings.Join(languageCodes(), ", ")+".").Default(defaultLanguage).EnumVar(&g.lang, languageCodes()...)
+	words.Flag("dense", "Show the words create --dense uses, more than 256 with a large enough --dictionary.").BoolVar(&g.dense)
+	words.Flag("format", "Output format: text, with a line of value and word for every word, or json.").Default("text").EnumVar(&g.format, "text", "json")
 
 	app.Version("Git Version: " + githash + "\nBuild: " + buildstamp + "\n")
 
-	kingpin.MustParse(app.Parse(os.Args[1:]))
+	kingpin.MustParse(app.Parse(stdioArgs(os.Args[1:])))
 }
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	sssa "github.com/SSSaaS/sssa-golang"
+)
+
+// splitJoinedFiles splits the contents of a text shares file that is several
+// files put together, like the files of single shares, at their header
+// lines. Every part starts with blank lines for the lines before it, so that
+// the lines in messages about it are the lines of the whole file.
+func splitJoinedFiles(data string) []string {
+	lines := strings.Split(data, "\n")
+	var starts []int
+	for i, s := range lines {
+		if strings.HasPrefix(strings.TrimSpace(s), headerPrefix) {
+			starts = append(starts, i)
+		}
+	}
+	if len(starts) < 2 {
+		return nil
+	}
+	starts[0] = 0
+	starts = append(starts, len(lines))
+
+	var parts []string
+	for i := 0; i+1 < len(starts); i++ {
+		parts = append(parts, strings.Repeat("\n", starts[i])+strings.Join(lines[starts[i]:starts[i+1]], "\n"))
+	}
+	return parts
+}
+
+// parseJoinedShares reads the shares of the parts of a file from
+// splitJoinedFiles. Each part is read as the file it was, and its shares
+// remember it, as the parts can be of different secrets.
+func (g *gsssa) parseJoinedShares(parts []string) (*sharesFile, error) {
+	joined := &sharesFile{}
+	for i, part := range parts {
+		file, err := g.parseShares(part)
+		if err != nil {
+			return nil, err
+		}
+		// How many shares are needed can differ between the parts.
+		file.warnings = append(file.warnings, file.stripThreshold()...)
+		for _, s := range file.shares {
+			s.from = file
+			joined.shares = append(joined.shares, s)
+		}
+		joined.warnings = append(joined.warnings, file.warnings...)
+		joined.unknownWords = append(joined.unknownWords, file.unknownWords...)
+		joined.malformed = append(joined.malformed, file.malformed...)
+		joined.wordCounts = append(joined.wordCounts, file.wordCounts...)
+		if i == 0 {
+			joined.version, joined.hasChecksum, joined.encoding, joined.lineCRC = file.version, file.hasChecksum, file.encoding, file.lineCRC
+			joined.min, joined.amount = file.min, file.amount
+			joined.created, joined.fingerprint, joined.note = file.created, file.fingerprint, file.note
+		}
+	}
+	return joined, nil
+}
+
+// shareGroup is the shares of a file that are of the same secret, as far
+// as the files put together into it tell.
+type shareGroup struct {
+	from   *sharesFile // the first of them is from
+	shares []parsedShare
+}
+
+// origin tells which secret the share is of, or is empty if it isn't known.
+func (s parsedShare) origin() string {
+	switch {
+	case s.from == nil:
+		return ""
+	case len(s.from.fingerprint) > 0:
+		return s.from.fingerprint
+	}
+	return s.from.created
+}
+
+// groups splits the shares by their origin, in the order of the file.
+func (f *sharesFile) groups() []shareGroup {
+	var groups []shareGroup
+	index := make(map[string]int)
+	for _, s := range f.shares {
+		i, ok := index[s.origin()]
+		if !ok {
+			i = len(groups)
+			index[s.origin()] = i
+			groups = append(groups, shareGroup{from: s.from})
+		}
+		groups[i].shares = append(groups[i].shares, s)
+	}
+	return groups
+}
+
+// names lists the shares of the group.
+func (sg shareGroup) names() string {
+	var names []string
+	for _, s := range sg.shares {
+		names = append(names, s.name())
+	}
+	return strings.Join(names, ", ")
+}
+
+func (sg shareGroup) String() string {
+	from := "of an unknown secret"
+	if sg.from != nil && len(sg.from.fingerprint) > 0 {
+		from = "fingerprint " + sg.from.fingerprint
+	} else if sg.from != nil && len(sg.from.created) > 0 {
+		from = "created at " + sg.from.created
+	}
+	return fmt.Sprintf("%s (%s)", sg.names(), from)
+}
+
+// chooseGroup keeps only the shares of one secret when the file has shares
+// of several: the group chosen with --group, or else the largest one.
+func (g *gsssa) chooseGroup(parsed *sharesFile) {
+	groups := parsed.groups()
+	if g.shareGroup < 0 || (g.shareGroup > 1 && g.shareGroup > len(groups)) {
+		fmt.Fprintf(os.Stderr, "There is no group %d of shares in %s, the shares are of %d secrets.\n", g.shareGroup, g.revealFiles(), len(groups))
+		os.Exit(1)
+	}
+	if len(groups) < 2 {
+		return
+	}
+
+	described := ""
+	for i, sg := range groups {
+		described += fmt.Sprintf("  group %d: %s\n", i+1, sg)
+	}
+	chosen := g.shareGroup - 1
+	if chosen < 0 {
+		chosen = 0
+		for i, sg := range groups {
+			if len(sg.shares) > len(groups[chosen].shares) {
+				chosen = i
+			}
+		}
+	}
+	fmt.Fprintf(os.Stderr, "WARNING: The shares in %s are of %d different secrets:\n%sOnly group %d is used, choose another one with --group.\n", g.revealFiles(), len(groups), described, chosen+1)
+	parsed.shares = groups[chosen].shares
+	if from := groups[chosen].from; from != nil {
+		parsed.hasChecksum, parsed.checksumUnknown, parsed.min, parsed.amount = from.hasChecksum, from.checksumUnknown, from.min, from.amount
+		parsed.fingerprint, parsed.created = from.fingerprint, from.created
+	}
+}
+
+// maxConsistentShares is how many shares consistentShares tries at most, as
+// it combines every min of them.
+const maxConsistentShares = 12
+
+// consistentShares looks for min of the shares that combine to a secret
+// with a matching checksum, for when combining all of them doesn't. Files
+// without a fingerprint can't tell shares of different secrets apart
+// otherwise. It returns nil if there are none.
+func consistentShares(shares []parsedShare, min int) ([]parsedShare, []byte) {
+	if min < 2 || len(shares) <= min || len(shares) > maxConsistentShares {
+		return nil, nil
+	}
+	chosen := make([]int, min)
+	var try func(next, n int) ([]parsedShare, []byte)
+	try = func(next, n int) ([]parsedShare, []byte) {
+		if n == min {
+			subset := &sharesFile{}
+			for _, i := range chosen {
+				subset.shares = append(subset.shares, shares[i])
+			}
+			res, err := sssa.Combine(subset.shareStrings())
+			if err != nil {
+				return nil, nil
+			}
+			secret, err := verifyChecksum(unpackSecret(res))
+			if err != nil {
+				return nil, nil
+			}
+			return subset.shares, secret
+		}
+		for i := next; i < len(shares); i++ {
+			chosen[n] = i
+			if subset, secret := try(i+1, n+1); subset != nil {
+				return subset, secret
+			}
+		}
+		return nil, nil
+	}
+	return try(0, 0)
+}
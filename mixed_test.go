@@ -0,0 +1,90 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// joinedShares creates the shares of every secret in a directory of its
+// own with the create flags, and returns the shares files put together.
+func joinedShares(t *testing.T, secrets []string, create ...[]string) string {
+	t.Helper()
+	var joined string
+	for i, secret := range secrets {
+		dir := t.TempDir()
+		mustRunGsssa(t, dir, secret, append([]string{"create", "--secret-stdin", "--no-print"}, create[i]...)...)
+		data, err := ioutil.ReadFile(filepath.Join(dir, "shares.txt"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		joined += string(data)
+	}
+	return joined
+}
+
+// Shares files of different secrets put together are told apart by their
+// fingerprints, and the secret with the most shares is
+// revealed unless --group chooses another one.
+func TestRevealJoinedFiles(t *testing.T) {
+	name := writeTestFile(t, "joined.txt", joinedShares(t, []string{"secret a", "secret b"}, []string{"--fingerprint"}, []string{"--fingerprint", "--amount", "4"}))
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{nil, "secret b"},
+		{[]string{"--group", "1"}, "secret a"},
+		{[]string{"--group", "2"}, "secret b"},
+	}
+	for _, test := range tests {
+		run := mustRunGsssa(t, filepath.Dir(name), "", append([]string{"reveal", "--raw", "-f", name}, test.args...)...)
+		if run.stdout != test.want {
+			t.Errorf("%v: revealed %q, want %q", test.args, run.stdout, test.want)
+		}
+		if !strings.Contains(run.stderr, "are of 2 different secrets:\n  group 1: share 1, share 2, share 3 (fingerprint ") || !strings.Contains(run.stderr, "  group 2: share 1, share 2, share 3, share 4 (fingerprint ") {
+			t.Errorf("%v: the groups aren't listed:\n%s", test.args, run.stderr)
+		}
+	}
+	if run := runGsssa(t, filepath.Dir(name), "", "reveal", "-f", name, "--group", "3"); run.ok || !strings.Contains(run.stderr, "There is no group 3 of shares") {
+		t.Errorf("--group 3: ok %v:\n%s", run.ok, run.stderr)
+	}
+}
+
+// Without anything that tells the secrets apart, min shares that combine to
+// a secret with a matching checksum are used.
+func TestConsistentShares(t *testing.T) {
+	data := joinedShares(t, []string{"secret a", "secret b"}, []string{"--no-timestamp"}, []string{"--no-timestamp"})
+	parsed, err := testGsssa().parseSharesData(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed.shares) != 6 || len(parsed.groups()) != 1 {
+		t.Fatalf("read %d shares in %d groups, want 6 in 1", len(parsed.shares), len(parsed.groups()))
+	}
+	a1, a2, b1 := parsed.shares[0], parsed.shares[1], parsed.shares[3]
+
+	tests := []struct {
+		shares []parsedShare
+		min    int
+		want   string
+	}{
+		{[]parsedShare{a1, b1, a2}, 2, "secret a"},
+		{[]parsedShare{b1, a1, parsed.shares[4]}, 2, "secret b"},
+		{[]parsedShare{a1, b1, parsed.shares[5]}, 2, "secret b"},
+		{[]parsedShare{a1, b1}, 2, ""},
+		{[]parsedShare{a1, b1, a2}, 3, ""},
+	}
+	for i, test := range tests {
+		subset, secret := consistentShares(test.shares, test.min)
+		if string(secret) != test.want || (subset != nil) != (len(test.want) > 0) {
+			t.Errorf("%d: %d shares with the secret %q, want %q", i+1, len(subset), secret, test.want)
+		}
+	}
+
+	name := writeTestFile(t, "joined.txt", data)
+	run := mustRunGsssa(t, filepath.Dir(name), "", "reveal", "--raw", "-f", name)
+	if run.stdout != "secret a" || !strings.Contains(run.stderr, "Only these are used: share 1, share 2.") {
+		t.Errorf("revealed %q:\n%s", run.stdout, run.stderr)
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// encodeSecret returns the revealed secret in the chosen --out-format. The
+// hex and base64 forms end with a newline when lineEnd is set, plain secrets
+// are never changed.
+func (g *gsssa) encodeSecret(secret []byte, lineEnd bool) []byte {
+	var encoded string
+	switch g.outFormat {
+	case "hex":
+		encoded = hex.EncodeToString(secret)
+	case "base64":
+		encoded = base64.StdEncoding.EncodeToString(secret)
+	default:
+		return secret
+	}
+	if lineEnd {
+		encoded += "\n"
+	}
+	return []byte(encoded)
+}
+
+// isPrintableText reports whether the secret is valid UTF-8 made of
+// printable characters and line breaks, so it can be shown on a terminal
+// without messing it up.
+func isPrintableText(secret []byte) bool {
+	if !utf8.Valid(secret) {
+		return false
+	}
+	for _, r := range string(secret) {
+		if !unicode.IsPrint(r) && r != '\n' && r != '\r' && r != '\t' {
+			return false
+		}
+	}
+	return true
+}
+
+// printSecret shows the revealed secret after "RESULT:". Multi-line secrets
+// start on their own line and are written exactly as they are, with a newline
+// added only if they don't end with one.
+func printSecret(secret []byte) {
+	if bytes.IndexByte(secret, '\n') >= 0 {
+		fmt.Println("RESULT:")
+	} else {
+		fmt.Print("RESULT: ")
+	}
+	os.Stdout.Write(secret)
+	if !bytes.HasSuffix(secret, []byte("\n")) {
+		fmt.Println()
+	}
+}
+
+// writeSecretFile writes the secret to a file only the current user can read.
+// An existing file is only replaced when force is set.
+func writeSecretFile(filename string, secret []byte, force bool) error {
+	flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if force {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+
+	f, err := os.OpenFile(filename, flags, 0600)
+	if os.IsExist(err) {
+		return fmt.Errorf("the file \"%s\" already exists. To force overwriting, use --force flag", filename)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := f.Chmod(0600); err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := f.Write(secret); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// copySecret puts the secret on the clipboard and waits for the timeout to
+// clear it again. Interrupting the wait clears it right away.
+func (g *gsssa) copySecret(secret []byte) {
+	cb, err := findClipboard()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := cb.write(secret); err != nil {
+		fmt.Fprintf(os.Stderr, "Could not copy to the clipboard: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Copied to clipboard, clearing in %s.\n", g.copyTimeout)
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	select {
+	case <-time.After(g.copyTimeout):
+	case <-interrupt:
+	}
+
+	if err := cb.clear(secret); err != nil {
+		fmt.Fprintf(os.Stderr, "Could not clear the clipboard: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Clipboard cleared.\n")
+}
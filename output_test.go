@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestIsPrintableText(t *testing.T) {
+	tests := []struct {
+		secret    string
+		printable bool
+	}{
+		{"correct horse battery staple", true},
+		{"line one\nline two\r\n\tindented", true},
+		{"Grüße aus Köln", true},
+		{"パスワード", true},
+		{"", true},
+		{"\x00", false},
+		{"text\x1b[2J", false},
+		{"\xff\xfe", false},
+		{"bell\a", false},
+		{"\u200b", false},
+	}
+	for _, test := range tests {
+		if got := isPrintableText([]byte(test.secret)); got != test.printable {
+			t.Errorf("%q: printable %v, want %v", test.secret, got, test.printable)
+		}
+	}
+}
+
+// A result that isn't text, like from wrong shares, is shown as a hex dump
+// unless --force-text is given.
+func TestRevealNotText(t *testing.T) {
+	binary := "\x1b[2J\x00\xff binary"
+	tests := []struct {
+		secret string
+		flags  []string
+		stdout string
+		dump   bool // the warning about the hex dump
+	}{
+		{"plain text", nil, "RESULT: plain text\n", false},
+		{binary, nil, hex.Dump([]byte(binary)), true},
+		{binary, []string{"--quiet"}, hex.Dump([]byte(binary)), true},
+		{binary, []string{"--force-text", "--quiet"}, binary + "\n", false},
+		{binary, []string{"--out-format", "hex", "--quiet"}, hex.EncodeToString([]byte(binary)) + "\n", false},
+	}
+	for _, test := range tests {
+		dir := t.TempDir()
+		mustRunGsssa(t, dir, "", "create", "--no-print", "--secret-file", writeTestFile(t, "secret", test.secret))
+		run := mustRunGsssa(t, dir, "", append([]string{"reveal"}, test.flags...)...)
+		if run.stdout != test.stdout {
+			t.Errorf("%q with %v: stdout %q, want %q", test.secret, test.flags, run.stdout, test.stdout)
+		}
+		if dump := strings.Contains(run.stderr, "The result is not text"); dump != test.dump {
+			t.Errorf("%q with %v: warned about a hex dump %v, want %v", test.secret, test.flags, dump, test.dump)
+		}
+	}
+
+	// Shares without a checksum that were copied wrong give random bytes.
+	dir := t.TempDir()
+	mustRunGsssa(t, dir, strings.Repeat("a secret that is long enough ", 3), "create", "--secret-stdin", "--no-print", "--no-checksum")
+	changeFirstWord(t, dir)
+	if run := mustRunGsssa(t, dir, "", "reveal"); !strings.Contains(run.stderr, "The result is not text") || strings.HasPrefix(run.stdout, "RESULT:") {
+		t.Errorf("wrong shares: stdout %q, stderr %q", run.stdout, run.stderr)
+	}
+}
@@ -0,0 +1,926 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// parsedShare is one share block read from a shares file.
+type parsedShare struct {
+	number int    // from the "# Share N" comment before it, 0 if there was none
+	holder string // from a "# Share for: name" comment
+	label  string // from a "# Share N (label)" comment
+	lines  int
+	words  int
+	data   []byte
+
+	min, amount int // from the thresholdPrefix of the share, 0 without one
+
+	from     *sharesFile // of the files put together into one, nil if it wasn't
+	filename string      // of the shares file, when shares of several are used
+	raw      bool        // the share was in the base64 of sssa, not encoded
+}
+
+// sharesFile is what was read from a shares file.
+type sharesFile struct {
+	shares      []parsedShare
+	version     int // of the format, 0 for text files without a header line
+	hasChecksum bool
+	threshold   bool // the shares start with a thresholdPrefix
+	min         int  // 0 if unknown
+	amount      int
+	warnings    []string // about the file as a whole
+	created     string   // when the shares were created, if the file says
+	fingerprint string   // of the secret, if the file has one
+	encoding    string
+	note        string
+	lineCRC     bool   // the share lines end with a line checksum
+	format      string // of the file, as for --format
+
+	// How the text format wrote the share lines, so convert can write them
+	// the same way.
+	groupSize      int // words between group separators, 0 without them
+	groupSeparator string
+	perLine        int  // words on a full line, 0 for the default
+	comments       bool // there are comment lines that gsssa doesn't write
+
+	unknownWords []unknownWord // that aren't in the dictionary
+	malformed    []string      // why the share blocks that were left out can't be read
+	wordCounts   []wordCount   // lines with more or fewer words than they should have
+
+	// The shares are words given with --share, which don't say whether
+	// they have a checksum.
+	checksumUnknown bool
+}
+
+// textHeaderInfo is what the header line of a text shares file says.
+type textHeaderInfo struct {
+	version    int  // 0 without a header line
+	legacy     bool // neither a header line nor a file checksum, like the files of the first gsssa
+	min        int
+	amount     int
+	encoding   string
+	dictFP     string
+	lang       string
+	checksum   bool
+	threshold  bool
+	lineCRC    bool
+	perLine    int // words on a full line, 0 if the header doesn't say
+	created    string
+	secretFP   string
+	separators map[string]bool
+	separator  string // the one create was given, empty if the header doesn't say
+}
+
+// parseTextHeader reads the header line of a text shares file. Files without
+// one get the settings of the files from before it: the words encoding and
+// no group separators.
+func parseTextHeader(data string) *textHeaderInfo {
+	h := &textHeaderInfo{
+		encoding:   defaultEncoding,
+		separators: map[string]bool{},
+	}
+	footer := false
+	for _, s := range unwrapLines(data) {
+		footer = footer || strings.HasPrefix(s, fileChecksumPrefix)
+		if !strings.HasPrefix(s, headerPrefix) {
+			continue
+		}
+		fields := strings.Fields(strings.TrimPrefix(s, headerPrefix))
+		if len(fields) == 0 {
+			continue
+		}
+		h.version, _ = strconv.Atoi(fields[0])
+		h.separators[defaultGroupSeparator] = true
+		for _, f := range fields[1:] {
+			kv := strings.SplitN(f, "=", 2)
+			if len(kv) != 2 {
+				continue
+			}
+			switch kv[0] {
+			case "min":
+				h.min, _ = strconv.Atoi(kv[1])
+			case "amount":
+				h.amount, _ = strconv.Atoi(kv[1])
+			case "encoding":
+				h.encoding = kv[1]
+			case "dictionary-fingerprint":
+				h.dictFP = kv[1]
+			case "lang":
+				h.lang = kv[1]
+			case "checksum":
+				h.checksum = true
+			case "share-prefix":
+				h.threshold = kv[1] == "threshold-1"
+			case "line-checksums":
+				h.lineCRC = true
+			case "words-per-line":
+				h.perLine, _ = strconv.Atoi(kv[1])
+			case "group-separator":
+				h.separators[kv[1]] = true
+				h.separator = kv[1]
+			case "created":
+				h.created = kv[1]
+			case "fingerprint":
+				h.secretFP = kv[1]
+			}
+		}
+	}
+	h.legacy = h.version == 0 && !footer
+	return h
+}
+
+// unwrapLines splits the contents of a text shares file into lines without
+// the whitespace around them. Comment lines that were re-wrapped, like when
+// the file was pasted into an email, are joined with their rest again: the
+// header line with the lines of settings after it, the note, which is
+// followed by a blank line, and the comments the shares and the footer start
+// with, which end with ")" and "back.". Other comment lines get single
+// spaces between their words.
+func unwrapLines(data string) []string {
+	lines, _ := unwrapNumberedLines(data)
+	return lines
+}
+
+// unwrapNumberedLines is unwrapLines that also returns the line of the file
+// each of the lines starts on, from 1.
+func unwrapNumberedLines(data string) ([]string, []int) {
+	var lines []string
+	var numbers []int
+	for i, s := range strings.Split(data, "\n") {
+		s = strings.TrimSpace(s)
+		if strings.HasPrefix(s, "#") && !strings.HasPrefix(s, strings.TrimRight(notePrefix, " ")) {
+			s = strings.Join(strings.Fields(s), " ")
+		}
+		if n := len(lines); n > 0 && len(s) > 0 && s[0] != '#' && wrappedComment(lines[n-1], s) {
+			lines[n-1] += " " + s
+			continue
+		}
+		lines = append(lines, s)
+		numbers = append(numbers, i+1)
+	}
+	return lines, numbers
+}
+
+// wrappedComment reports whether next is the rest of the comment line s.
+func wrappedComment(s, next string) bool {
+	switch {
+	case strings.HasPrefix(s, headerPrefix):
+		for _, f := range strings.Fields(next) {
+			if !strings.Contains(f, "=") {
+				return false
+			}
+		}
+		return true
+	case strings.HasPrefix(s, "# Share ") && strings.Contains(s, "("):
+		return !strings.HasSuffix(s, ")")
+	case strings.HasPrefix(s, "# You need "):
+		return !strings.HasSuffix(s, "back.")
+	case strings.HasPrefix(s, strings.TrimRight(notePrefix, " ")):
+		// The note is followed by a blank line.
+		return true
+	}
+	return false
+}
+
+// positionError says where in the file the problem err is: on the line
+// fileLine of the file, which is the line-th line of the share name. A
+// wordError also says which word of the line it is about.
+func positionError(err error, name string, line, fileLine int) error {
+	at := fmt.Sprintf("%s, line %d", name, line)
+	if we, ok := err.(*wordError); ok {
+		at += fmt.Sprintf(", word %d", we.index)
+		err = we.err
+	}
+	if fileLine > 0 {
+		return fmt.Errorf("line %d (%s): %v", fileLine, at, err)
+	}
+	return fmt.Errorf("%s: %v", at, err)
+}
+
+// lineAt returns the line of the file of the line-th line of a share, from
+// 0 like line, or 0 if it isn't known.
+func lineAt(fileLines []int, line int) int {
+	if line < len(fileLines) {
+		return fileLines[line]
+	}
+	return 0
+}
+
+// rawShareLine returns the share on the line s of a text shares file if it
+// is a share in the base64 of sssa, like from another sssa tool, instead of
+// encoded. With --input-encoding auto a line that could also be a word of
+// the dictionary is an error. A line that the encoding of the file reads,
+// like every line of the raw encoding, is left to it.
+func (g *gsssa) rawShareLine(s string, enc shareEncoding) ([]byte, bool, error) {
+	if g.inputEncoding == "words" || strings.ContainsAny(s, " \t") {
+		return nil, false, nil
+	}
+	data, ok := rawShare(s)
+	if !ok || g.inputEncoding == "base64" {
+		return data, ok, nil
+	}
+	_, unknown, err := enc.decodeLine([]string{s})
+	switch {
+	case err != nil || len(unknown) > 0:
+		return data, true, nil
+	case len(encodingFingerprint(enc)) == 0:
+		return nil, false, nil
+	}
+	return nil, false, fmt.Errorf("\"%s\" can be a share in base64 or a word of the dictionary, choose which with --input-encoding", s)
+}
+
+// decodeCheckedLines decodes the lines of a share that end with a line
+// checksum, and describes the lines whose checksum doesn't match. fileLines
+// are the lines of the file the lines are on, if they are known.
+func decodeCheckedLines(enc shareEncoding, lines [][]string, fileLines []int, name string) ([]byte, []unknownWord, []string, error) {
+	var data []byte
+	var bad []string
+	var unknown []unknownWord
+	k := checksumWords(enc)
+	for i, line := range lines {
+		if len(line) == 0 {
+			continue
+		}
+		n := len(line) - k
+		if n < 0 {
+			n = 0
+		}
+		words, sum := line[:n], line[n:]
+		decoded, found, err := enc.decodeLine(words)
+		if err != nil {
+			return nil, nil, nil, positionError(err, name, i+1, lineAt(fileLines, i))
+		}
+		found = placeUnknownWords(found, name, i+1, 0)
+		expected, sumUnknown, err := enc.decodeLine(sum)
+		found = append(found, placeUnknownWords(sumUnknown, name, i+1, len(words))...)
+		for _, u := range found {
+			u.fileLine = lineAt(fileLines, i)
+			unknown = append(unknown, u)
+		}
+		if err != nil || len(expected) != 1 || expected[0] != lineChecksum(decoded) {
+			bad = append(bad, positionError(fmt.Errorf("checksum mismatch - re-check these %d words", len(words)), name, i+1, lineAt(fileLines, i)).Error())
+		}
+		data = append(data, decoded...)
+	}
+	return data, unknown, bad, nil
+}
+
+// lineCounts is how many words the lines of a share block have, without
+// group separators and line checksums.
+type lineCounts struct {
+	name      string
+	counts    []int
+	fileLines []int
+}
+
+// countWords counts the words of the lines of a share, without the last
+// checksum tokens of every line.
+func countWords(name string, lines [][]string, fileLines []int, checksum int) lineCounts {
+	c := lineCounts{name: name, fileLines: fileLines}
+	for _, line := range lines {
+		n := len(line) - checksum
+		if n < 0 {
+			n = 0
+		}
+		c.counts = append(c.counts, n)
+	}
+	return c
+}
+
+func (c lineCounts) total() int {
+	total := 0
+	for _, n := range c.counts {
+		total += n
+	}
+	return total
+}
+
+// wordCount is a line of a share with another number of words than
+// expected, which shifts everything after it when the share is decoded.
+type wordCount struct {
+	share    string
+	line     int // of the share, from 1
+	fileLine int // 0 if it isn't known
+	words    int
+	expected int
+	most     bool // expected is only what most lines have, they don't all agree
+}
+
+func (w wordCount) String() string {
+	s := fmt.Sprintf("%s, line %d has %d words, expected %d", w.share, w.line, w.words, w.expected)
+	if w.most {
+		s = fmt.Sprintf("%s, line %d has %d words, most lines like it have %d", w.share, w.line, w.words, w.expected)
+	}
+	if w.fileLine > 0 {
+		s += fmt.Sprintf(" (line %d of the file)", w.fileLine)
+	}
+	return s
+}
+
+// commonCount returns the number that is most often in counts, and whether
+// all of them are that number. It returns 0 without one that is more often
+// than all others.
+func commonCount(counts []int) (int, bool) {
+	seen := make(map[int]int)
+	for _, n := range counts {
+		seen[n]++
+	}
+	common, most, tied := 0, 0, false
+	for n, times := range seen {
+		switch {
+		case times > most:
+			common, most, tied = n, times, false
+		case times == most:
+			tied = true
+		}
+	}
+	if tied {
+		return 0, false
+	}
+	return common, len(seen) == 1
+}
+
+// checkWordCounts finds the lines of the shares with words missing or too
+// many. Every line of a share but the last one has perLine words, or as
+// many as the other lines have if the header doesn't say, and the last lines
+// of the shares have as many as each other. A share with as many words as
+// the others isn't checked, its line breaks may just have been moved.
+func checkWordCounts(shares []lineCounts, perLine int) []wordCount {
+	var found []wordCount
+	for i, s := range shares {
+		var totals, full, last []int
+		for j, other := range shares {
+			if j == i || len(other.counts) == 0 {
+				continue
+			}
+			totals = append(totals, other.total())
+			full = append(full, other.counts[:len(other.counts)-1]...)
+			last = append(last, other.counts[len(other.counts)-1])
+		}
+		if total, all := commonCount(totals); all && total == s.total() {
+			continue
+		}
+
+		for k, n := range s.counts {
+			var expected int
+			var all bool
+			switch {
+			case k == len(s.counts)-1 && perLine > 0 && n > perLine:
+				expected, all = perLine, true
+			case k == len(s.counts)-1:
+				expected, all = commonCount(last)
+			case perLine > 0:
+				expected, all = perLine, true
+			default:
+				others := append(append([]int{}, full...), s.counts[:k]...)
+				others = append(others, s.counts[k+1:len(s.counts)-1]...)
+				expected, all = commonCount(others)
+			}
+			if expected > 0 && n != expected {
+				found = append(found, wordCount{share: s.name, line: k + 1, fileLine: lineAt(s.fileLines, k), words: n, expected: expected, most: !all})
+			}
+		}
+	}
+	return found
+}
+
+// labelComment is the comment before a share with a label.
+var labelComment = regexp.MustCompile(`^# Share (\d+) \((.+)\)$`)
+
+// checkVersion refuses files of a newer format version than this gsssa
+// knows, as they could be read wrong without anything noticing. With
+// --force-parse they are read anyway.
+func (g *gsssa) checkVersion(version, supported int) error {
+	if version <= supported {
+		return nil
+	}
+	if !g.forceParse {
+		return fmt.Errorf("this file was created by a newer gsssa (v%d); please upgrade. Only up to v%d is supported here", version, supported)
+	}
+	fmt.Fprintf(os.Stderr, "WARNING: This file was created by a newer gsssa (v%d) and is read with --force-parse as if it were v%d. This is UNSAFE, the secret can be wrong without any error.\n", version, supported)
+	return nil
+}
+
+// applyParseMode sets --parse-mode from --strict or --lenient, and turns
+// off everything that reads shares that aren't written exactly as create
+// wrote them with strict: words in another case or only their start, moved
+// line breaks, extra whitespace, wrapped comment lines, correcting unknown
+// words and leaving out share blocks that can't be read. Unicode
+// normalization and Windows line endings are still accepted, as they can't
+// be seen. normal accepts all of them, lenient also reads unknown words as
+// the first word of the dictionary.
+func (g *gsssa) applyParseMode() {
+	switch {
+	case g.strict && g.lenient:
+		fmt.Fprintf(os.Stderr, "--strict and --lenient can't be used together.\n")
+		os.Exit(1)
+	case g.strict:
+		g.parseMode = "strict"
+	case g.lenient:
+		g.parseMode = "lenient"
+	}
+	if g.parseMode != "strict" {
+		return
+	}
+	if g.autoCorrect {
+		fmt.Fprintf(os.Stderr, "--auto-correct can't be used with --parse-mode strict.\n")
+		os.Exit(1)
+	}
+	g.caseSensitive, g.minPrefix = true, 0
+}
+
+// layoutProblems describes the lines of a text shares file that aren't
+// written the way create writes them, for --parse-mode strict.
+func layoutProblems(data string) []string {
+	var problems []string
+	raw := strings.Split(data, "\n")
+	_, numbers := unwrapNumberedLines(data)
+	for i, n := range numbers {
+		s := raw[n-1]
+		if s != strings.TrimSpace(s) || (len(s) > 0 && s[0] != '#' && s != strings.Join(strings.Fields(s), " ")) {
+			problems = append(problems, fmt.Sprintf("line %d has extra whitespace", n))
+		}
+		if i+1 < len(numbers) && numbers[i+1] > n+1 {
+			problems = append(problems, fmt.Sprintf("line %d is wrapped onto the next lines", n))
+		}
+	}
+	return problems
+}
+
+// parseShares reads the share blocks from the contents of a shares file. A
+// block is made of lines of encoded share data and ends at a blank line.
+//
+// Files from the first versions of gsssa are only comments and lines of
+// dictionary words, without a header line or a file checksum. They are read
+// the way those versions did in every parse mode: every token is a whole
+// word in the case of the dictionary, without group separators or line
+// checksums, and no share block is left out.
+func (g *gsssa) parseShares(data string) (*sharesFile, error) {
+	if g.parseMode == "strict" {
+		if problems := layoutProblems(data); len(problems) > 0 {
+			return nil, fmt.Errorf("the file isn't written exactly as it was created, as --parse-mode strict needs:\n%s", strings.Join(problems, "\n"))
+		}
+	}
+	header := parseTextHeader(data)
+	if err := g.checkVersion(header.version, textFormatVersion); err != nil {
+		return nil, err
+	}
+	g.useLanguage(header.lang)
+	if header.legacy {
+		// Only whole words in the case of the dictionary.
+		caseSensitive, minPrefix, autoCorrect := g.caseSensitive, g.minPrefix, g.autoCorrect
+		g.caseSensitive, g.minPrefix, g.autoCorrect = true, 0, false
+		defer func() {
+			g.caseSensitive, g.minPrefix, g.autoCorrect = caseSensitive, minPrefix, autoCorrect
+		}()
+	}
+	enc, err := g.shareEncoding(header.encoding)
+	if err != nil {
+		return nil, err
+	}
+	if err := g.checkFingerprint(enc, header.dictFP); err != nil {
+		return nil, err
+	}
+
+	separators, lineCRC := header.separators, header.lineCRC
+
+	file := &sharesFile{
+		version:     header.version,
+		hasChecksum: header.checksum,
+		threshold:   header.threshold,
+		min:         header.min,
+		amount:      header.amount,
+		created:     header.created,
+		fingerprint: header.secretFP,
+		encoding:    header.encoding,
+		lineCRC:     header.lineCRC,
+
+		groupSeparator: header.separator,
+		perLine:        header.perLine,
+	}
+
+	var note []string
+	number, holder, label := 0, "", ""
+	var block [][]string // the word lines of the share being read, without group separators
+	var blockLines []int // the lines of the file they are on
+	var counted []lineCounts
+	storedChecksum := ""
+
+	// endBlock decodes the share made of the lines in block. A block that
+	// can't be decoded is left out and described in file.malformed.
+	endBlock := func() {
+		lines, fileLines := block, blockLines
+		block, blockLines = nil, nil
+		if len(lines) == 0 {
+			return
+		}
+		share := parsedShare{number: number, holder: holder, label: label, lines: len(lines)}
+		name := shareName(number, len(file.shares)+1)
+		number, holder, label = 0, "", ""
+		checksum := 0
+		if lineCRC {
+			checksum = checksumWords(enc)
+		}
+		counted = append(counted, countWords(name, lines, fileLines, checksum))
+
+		if lineCRC {
+			data, unknown, bad, err := decodeCheckedLines(enc, lines, fileLines, name)
+			if err != nil {
+				file.malformed = append(file.malformed, err.Error())
+				return
+			}
+			if len(bad) > 0 && g.parseMode != "strict" {
+				// The line breaks may have been moved, try the lines create
+				// writes.
+				perLine := len(enc.encode(make([]byte, 32))[0]) + checksum
+				rewrapped := rewrapLines(lines, perLine)
+				// Their lines of the file aren't known anymore.
+				if d, u, b, err := decodeCheckedLines(enc, rewrapped, nil, name); err == nil && len(b) == 0 {
+					file.warnings = append(file.warnings, fmt.Sprintf("The line breaks of %s were moved, its lines were put back together as they were created.", name))
+					data, unknown, bad, lines = d, u, nil, rewrapped
+					// Its lines are the ones create wrote now.
+					counted = counted[:len(counted)-1]
+				}
+			}
+			if len(bad) > 0 {
+				file.malformed = append(file.malformed, strings.Join(bad, "\n"))
+				return
+			}
+			file.unknownWords = append(file.unknownWords, unknown...)
+			share.data = data
+			for _, line := range lines {
+				share.words += len(line) - checksum
+			}
+			file.shares = append(file.shares, share)
+			return
+		}
+
+		// The lines are decoded as one, so it doesn't matter where their
+		// line breaks are.
+		var tokens []string
+		var starts []int
+		for _, line := range lines {
+			starts = append(starts, len(tokens))
+			tokens = append(tokens, line...)
+		}
+		// lineOf returns the line of the index-th token, from 0, and which
+		// token of that line it is.
+		lineOf := func(index int) (int, int) {
+			line := 0
+			for line+1 < len(starts) && starts[line+1] < index {
+				line++
+			}
+			return line, index - starts[line]
+		}
+		data, unknown, err := enc.decodeLine(tokens)
+		if we, ok := err.(*wordError); ok {
+			line, index := lineOf(we.index)
+			file.malformed = append(file.malformed, positionError(&wordError{index, we.err}, name, line+1, fileLines[line]).Error())
+			return
+		} else if err != nil {
+			file.malformed = append(file.malformed, positionError(err, name, 1, fileLines[0]).Error())
+			return
+		}
+		for _, u := range placeUnknownWords(unknown, name, 0, 0) {
+			line, index := lineOf(u.index)
+			u.line, u.index, u.fileLine = line+1, index, fileLines[line]
+			file.unknownWords = append(file.unknownWords, u)
+		}
+		share.data, share.words = data, len(tokens)
+		file.shares = append(file.shares, share)
+	}
+
+	lines, fileLines := unwrapNumberedLines(data)
+	for i, s := range lines {
+		if len(s) > 0 && s[0] == '#' {
+			// The comments before a share and the footer end the share
+			// before them, other comments can be anywhere, even between the
+			// lines of a share.
+			if strings.HasPrefix(s, "# Share ") || strings.HasPrefix(s, "# You need ") || strings.HasPrefix(s, fileChecksumPrefix) {
+				endBlock()
+			}
+
+			var n, min, amount int
+			var name string
+			if strings.HasPrefix(s, strings.TrimRight(notePrefix, " ")) {
+				note = append(note, strings.TrimPrefix(strings.TrimPrefix(s, notePrefix), strings.TrimRight(notePrefix, " ")))
+			} else if strings.HasPrefix(s, fileChecksumPrefix) {
+				storedChecksum = strings.TrimSpace(strings.TrimPrefix(s, fileChecksumPrefix))
+			} else if _, err := fmt.Sscanf(s, "# Share for: %s (%d of %d, need %d)", &name, &n, &amount, &min); err == nil {
+				number, holder = n, name
+				file.min, file.amount = min, amount
+			} else if m := labelComment.FindStringSubmatch(s); m != nil {
+				number, _ = strconv.Atoi(m[1])
+				label = m[2]
+			} else if _, err := fmt.Sscanf(s, "# Share %d", &n); err == nil {
+				number = n
+			} else if _, err := fmt.Sscanf(s, "# You need %d shares out of these %d shares", &min, &amount); err == nil {
+				file.min, file.amount = min, amount
+			} else if !strings.HasPrefix(s, headerPrefix) {
+				file.comments = true
+			}
+			continue
+		}
+
+		if len(s) == 0 {
+			endBlock()
+			continue
+		}
+
+		data, raw, err := g.rawShareLine(s, enc)
+		if err != nil {
+			return nil, positionError(err, shareName(number, len(file.shares)+1), 1, fileLines[i])
+		}
+		if raw {
+			endBlock()
+			file.shares = append(file.shares, parsedShare{number: number, holder: holder, label: label, lines: 1, data: data, raw: true})
+			number, holder, label = 0, "", ""
+			continue
+		}
+
+		var seedWords []string
+		for _, w := range strings.Fields(s) {
+			if !separators[w] {
+				seedWords = append(seedWords, w)
+			} else if file.groupSize == 0 && w == file.groupSeparator {
+				file.groupSize = len(seedWords)
+			}
+		}
+		block = append(block, seedWords)
+		blockLines = append(blockLines, fileLines[i])
+	}
+	// The last share doesn't need a blank line after it.
+	endBlock()
+	if header.legacy && len(file.malformed) > 0 {
+		return nil, fmt.Errorf("the file has no header line, so its share blocks are never left out, but these can't be read:\n%s", strings.Join(file.malformed, "\n"))
+	}
+	file.wordCounts = checkWordCounts(counted, header.perLine)
+
+	file.note = strings.Join(note, "\n")
+	if len(storedChecksum) == 0 {
+		file.warnings = append(file.warnings, "The file has no file checksum, it was created by an older gsssa. Changes to its share lines can't be found this way.")
+	} else if sum := fileChecksum(data, enc); sum != storedChecksum && fileChecksum(data, nil) != storedChecksum {
+		// The shares given to reveal with --share or pasted into it have the
+		// checksum of their words as they are written, not as they are read.
+		// The problems that are found word by word change the checksum too.
+		// They say more about what is wrong than the checksum can.
+		mismatch := fmt.Sprintf("The file checksum doesn't match (%s, the file says %s)", sum, storedChecksum)
+		switch {
+		case len(file.malformed) > 0:
+			file.warnings = append(file.warnings, mismatch+", like the share blocks that can't be read.")
+		case len(file.wordCounts) > 0:
+			file.warnings = append(file.warnings, mismatch+", like the lines with words missing or too many.")
+		case len(file.unknownWords) > 0:
+			file.warnings = append(file.warnings, mismatch+", like the words that aren't in the dictionary.")
+		case g.parseMode == "strict":
+			return nil, fmt.Errorf("the file checksum doesn't match (%s, the file says %s): the share lines were changed or damaged since the file was created", sum, storedChecksum)
+		default:
+			file.warnings = append(file.warnings, mismatch+": the share lines were changed or damaged since the file was created. The checksum of the secret, if it has one, tells whether they can still be used.")
+		}
+	}
+	return file, nil
+}
+
+// parseJSONShares reads the shares from a file written with --format json.
+func (g *gsssa) parseJSONShares(data string) (*sharesFile, error) {
+	var doc jsonShares
+	if err := json.Unmarshal([]byte(data), &doc); err != nil {
+		return nil, fmt.Errorf("can't parse the JSON shares file: %v", err)
+	}
+	if doc.Format != jsonFormatName {
+		return nil, fmt.Errorf("this is not a gsssa JSON shares file")
+	}
+	if err := g.checkVersion(doc.Version, jsonFormatVersion); err != nil {
+		return nil, err
+	}
+
+	g.useLanguage(doc.Lang)
+	enc, err := g.shareEncoding(doc.Encoding)
+	if err != nil {
+		return nil, err
+	}
+	if err := g.checkFingerprint(enc, doc.DictFP); err != nil {
+		return nil, err
+	}
+
+	file := &sharesFile{
+		hasChecksum: len(doc.Checksum) > 0,
+		threshold:   doc.Prefix == "threshold-1",
+		min:         doc.Min,
+		amount:      doc.Amount,
+		created:     doc.Created,
+		fingerprint: doc.SecretFP,
+		encoding:    doc.Encoding,
+		note:        doc.Note,
+	}
+	for _, s := range doc.Shares {
+		share := parsedShare{number: s.Index, holder: s.Holder, label: s.Label, lines: len(s.Lines)}
+		name := shareName(s.Index, len(file.shares)+1)
+		malformed := false
+		for i, line := range s.Lines {
+			decoded, unknown, err := enc.decodeLine(line)
+			if err != nil {
+				file.malformed = append(file.malformed, positionError(err, name, i+1, 0).Error())
+				malformed = true
+				break
+			}
+			file.unknownWords = append(file.unknownWords, placeUnknownWords(unknown, name, i+1, 0)...)
+			share.data = append(share.data, decoded...)
+			share.words += len(line)
+		}
+		if !malformed {
+			file.shares = append(file.shares, share)
+		}
+	}
+	return file, nil
+}
+
+// shareName is how the share with number, or else the block-th share of
+// the file, is called in messages about its words.
+func shareName(number, block int) string {
+	if number > 0 {
+		return fmt.Sprintf("share %d", number)
+	}
+	return fmt.Sprintf("share block %d", block)
+}
+
+// name is how the share is called in messages.
+func (s *parsedShare) name() string {
+	name := "unnumbered share"
+	if s.number > 0 {
+		name = fmt.Sprintf("share %d", s.number)
+	}
+	if len(s.filename) > 0 {
+		name += fmt.Sprintf(" from \"%s\"", s.filename)
+	}
+	return name
+}
+
+// stripThreshold removes the thresholdPrefix from the shares, and warns when
+// the shares don't agree on how many are needed. Shares
+// too short to have it are left out as malformed.
+func (f *sharesFile) stripThreshold() []string {
+	if !f.threshold {
+		return nil
+	}
+
+	var warnings []string
+	var kept []parsedShare
+	for _, s := range f.shares {
+		if s.raw {
+			kept = append(kept, s)
+			continue
+		}
+		if len(s.data) < thresholdPrefixSize {
+			f.malformed = append(f.malformed, fmt.Sprintf("the %s is too short to be a share", s.name()))
+			continue
+		}
+		s.min, s.amount = int(s.data[0]), int(s.data[1])
+		s.data = s.data[thresholdPrefixSize:]
+
+		if f.min == 0 {
+			f.min, f.amount = s.min, s.amount
+		} else if s.min != f.min || s.amount != f.amount {
+			warnings = append(warnings, fmt.Sprintf("The %s says %d of %d shares are needed, but the file or other shares say %d of %d. The shares are probably from different secrets.", s.name(), s.min, s.amount, f.min, f.amount))
+		}
+		kept = append(kept, s)
+	}
+	f.shares = kept
+	return warnings
+}
+
+// unnumbered warns about the shares without a share number.
+func (f *sharesFile) unnumbered() []string {
+	var warnings []string
+	for i, s := range f.shares {
+		if s.number == 0 {
+			warnings = append(warnings, fmt.Sprintf("Share block %d has no share number.", i+1))
+		}
+	}
+	return warnings
+}
+
+// dedupe drops shares that are in the file more than once, as sssa can't
+// combine a share with itself. It returns warnings about them and about
+// share numbers that are used twice.
+func (f *sharesFile) dedupe() []string {
+	var warnings []string
+	var kept []parsedShare
+	byNumber := make(map[int]int)
+	for _, s := range f.shares {
+		duplicate := false
+		for _, k := range kept {
+			if bytes.Equal(k.data, s.data) {
+				name := s.name()
+				name = strings.ToUpper(name[:1]) + name[1:]
+				if k.number == s.number && k.filename == s.filename {
+					warnings = append(warnings, fmt.Sprintf("%s is in the file more than once, it is only used once.", name))
+				} else {
+					warnings = append(warnings, fmt.Sprintf("%s is the same as %s, it is only used once.", name, k.name()))
+				}
+				duplicate = true
+				break
+			}
+		}
+		if duplicate {
+			continue
+		}
+
+		if s.number > 0 {
+			if byNumber[s.number] > 0 {
+				warnings = append(warnings, fmt.Sprintf("There are two different shares numbered %d. At most one of them can be right.", s.number))
+			}
+			byNumber[s.number]++
+		}
+		kept = append(kept, s)
+	}
+	f.shares = kept
+	return warnings
+}
+
+// keepShares leaves only the shares numbered numbers, a comma-separated
+// list like "1,4", in the order of the file.
+func (f *sharesFile) keepShares(numbers string) error {
+	wanted := make(map[int]bool)
+	for _, n := range strings.Split(numbers, ",") {
+		number, err := strconv.Atoi(strings.TrimSpace(n))
+		if err != nil || number < 1 {
+			return fmt.Errorf("\"%s\" is not a share number", strings.TrimSpace(n))
+		}
+		wanted[number] = true
+	}
+
+	var kept []parsedShare
+	found := make(map[int]bool)
+	for _, s := range f.shares {
+		if wanted[s.number] {
+			kept = append(kept, s)
+			found[s.number] = true
+		}
+	}
+	var missing []string
+	for _, n := range strings.Split(numbers, ",") {
+		if number, _ := strconv.Atoi(strings.TrimSpace(n)); !found[number] {
+			missing = append(missing, strconv.Itoa(number))
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("the file has no share %s", strings.Join(missing, ", "))
+	}
+	f.shares = kept
+	return nil
+}
+
+// tooFewShares says how many shares are missing to reveal the secret, or is
+// empty if there are enough or it isn't known how many are needed.
+func (f *sharesFile) tooFewShares() string {
+	if len(f.shares) >= f.min {
+		return ""
+	}
+	found := "1 share"
+	if len(f.shares) != 1 {
+		found = fmt.Sprintf("%d shares", len(f.shares))
+	}
+	return fmt.Sprintf("Found %s, need at least %d - collect more shares and re-run.", found, f.min)
+}
+
+// shareStrings returns the shares as sssa.Combine expects them.
+func (f *sharesFile) shareStrings() []string {
+	var shares []string
+	for _, s := range f.shares {
+		shares = append(shares, bytesToShare(s.data))
+	}
+	return shares
+}
+
+// report describes which shares were used from the files, for after a
+// reveal.
+func (f *sharesFile) report(filenames ...string) string {
+	var used []string
+	for _, s := range f.shares {
+		name := s.name()
+		if len(s.holder) > 0 {
+			name += " of " + s.holder
+		}
+		if len(s.label) > 0 {
+			name += " \"" + s.label + "\""
+		}
+		if s.raw {
+			used = append(used, name+" (in base64)")
+			continue
+		}
+		used = append(used, fmt.Sprintf("%s (%d words on %d lines)", name, s.words, s.lines))
+	}
+
+	report := fmt.Sprintf("Used %d share blocks from \"%s\": %s.\n", len(f.shares), strings.Join(filenames, "\", \""), strings.Join(used, ", "))
+	if f.min > 0 {
+		report += fmt.Sprintf("The file says %d of its %d shares are needed.\n", f.min, f.amount)
+	}
+	return report
+}
@@ -0,0 +1,303 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// readTestdata returns the contents of a file in testdata.
+func readTestdata(t *testing.T, name string) string {
+	t.Helper()
+	data, err := ioutil.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// Files saved on Windows end their lines with "\r\n", maybe only some of
+// them.
+func TestRevealWindowsLineEndings(t *testing.T) {
+	for _, name := range []string{"crlf.txt", "mixed-line-endings.txt"} {
+		if secret := revealData(t, testGsssa(), readTestdata(t, name)); string(secret) != "windows line endings" {
+			t.Errorf("%s: revealed %q", name, secret)
+		}
+	}
+}
+
+// The last share of a file that ends right after its last word must not be
+// left out.
+func TestRevealWithoutTrailingNewline(t *testing.T) {
+	data := readTestdata(t, "no-trailing-newline.txt")
+	parsed, err := testGsssa().parseSharesData(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed.shares) != 3 {
+		t.Fatalf("read %d shares, want 3", len(parsed.shares))
+	}
+	if secret := revealData(t, testGsssa(), data); string(secret) != "the last share counts" {
+		t.Errorf("revealed %q", secret)
+	}
+}
+
+// The file checksum is of the words as they are read, so writing only the
+// start of a word doesn't change it, but another word does.
+func TestFileChecksumOfWordStarts(t *testing.T) {
+	data := readTestdata(t, "text.golden")
+	want, err := testGsssa().parseSharesData(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	started := strings.Replace(data, " alcohol ", " alco ", 1)
+	parsed, err := testGsssa().parseSharesData(started)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed.warnings) > 0 {
+		t.Errorf("warnings about the start of a word: %v", parsed.warnings)
+	}
+	if !bytes.Equal(parsed.shares[0].data, want.shares[0].data) {
+		t.Errorf("share 1 is %x, want %x", parsed.shares[0].data, want.shares[0].data)
+	}
+}
+
+// A share line that was changed to other dictionary words is only found by
+// the file checksum. Its mismatch is an error with --parse-mode strict and
+// a warning otherwise, also when other words are only their start.
+func TestFileChecksumMismatch(t *testing.T) {
+	data := strings.Replace(readTestdata(t, "text.golden"), " bamboo ", " banana ", 1)
+	for _, mode := range []string{"strict", "normal", "lenient"} {
+		changed := data
+		if mode != "strict" {
+			changed = strings.Replace(changed, " alcohol ", " alco ", 1)
+		}
+		g := testGsssa()
+		g.parseMode = mode
+		g.applyParseMode()
+		parsed, err := g.parseSharesData(changed)
+		if mode == "strict" {
+			if err == nil || !strings.Contains(err.Error(), "file checksum doesn't match") {
+				t.Errorf("--parse-mode strict: got %v, want a file checksum mismatch", err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("--parse-mode %s: %v", mode, err)
+		}
+		if len(parsed.warnings) != 1 || !strings.Contains(parsed.warnings[0], "file checksum doesn't match") {
+			t.Errorf("--parse-mode %s: warnings %v, want a file checksum mismatch", mode, parsed.warnings)
+		}
+		if len(parsed.shares) != 3 {
+			t.Errorf("--parse-mode %s: read %d shares, want 3", mode, len(parsed.shares))
+		}
+	}
+}
+
+// A file of a newer format version is refused, unless it is read anyway
+// with --force-parse.
+func TestNewerVersion(t *testing.T) {
+	tests := []struct {
+		name, data string
+	}{
+		{"text", strings.Replace(readTestdata(t, "text.golden"), "# gsssa v2 ", "# gsssa v3 ", 1)},
+		{"json", strings.Replace(readTestdata(t, "json.golden"), `"version": 1,`, `"version": 3,`, 1)},
+	}
+	for _, test := range tests {
+		_, err := testGsssa().parseSharesData(test.data)
+		if err == nil || !strings.Contains(err.Error(), "this file was created by a newer gsssa (v3); please upgrade") {
+			t.Errorf("%s: got %v, want to upgrade", test.name, err)
+		}
+
+		g := testGsssa()
+		g.forceParse = true
+		parsed, err := g.parseSharesData(test.data)
+		if err != nil {
+			t.Fatalf("%s with --force-parse: %v", test.name, err)
+		}
+		if len(parsed.shares) != 3 {
+			t.Errorf("%s with --force-parse: read %d shares, want 3", test.name, len(parsed.shares))
+		}
+	}
+
+	// reveal says the same, and --force-parse says that it is unsafe.
+	dir := t.TempDir()
+	mustRunGsssa(t, dir, "from the future", "create", "--secret-stdin", "--no-print")
+	name := filepath.Join(dir, "shares.txt")
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(name, bytes.Replace(data, []byte("# gsssa v2 "), []byte("# gsssa v9 "), 1), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if run := runGsssa(t, dir, "", "reveal"); run.ok || !strings.Contains(run.stderr, "newer gsssa (v9); please upgrade") || strings.Contains(run.stdout, "from the future") {
+		t.Errorf("reveal: ok %v, want to upgrade:\n%s%s", run.ok, run.stdout, run.stderr)
+	}
+	run := mustRunGsssa(t, dir, "", "reveal", "--force-parse", "--raw")
+	if run.stdout != "from the future" || !strings.Contains(run.stderr, "UNSAFE") {
+		t.Errorf("reveal --force-parse: revealed %q with the warning:\n%s", run.stdout, run.stderr)
+	}
+}
+
+// A comment between the lines of a share, like a note written by hand,
+// keeps the lines before it.
+func TestCommentInsideShare(t *testing.T) {
+	data := readTestdata(t, "text.golden")
+	want, err := testGsssa().parseSharesData(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(data, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, "# Share ") {
+			lines[i+1] += "\n# checked twice\n#"
+		}
+	}
+	parsed, err := testGsssa().parseSharesData(strings.Join(lines, "\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed.shares) != 3 || len(parsed.malformed) > 0 || len(parsed.wordCounts) > 0 {
+		t.Fatalf("read %d shares, malformed blocks %v, word counts %v", len(parsed.shares), parsed.malformed, parsed.wordCounts)
+	}
+	for i, share := range parsed.shares {
+		if !bytes.Equal(share.data, want.shares[i].data) {
+			t.Errorf("share %d is %x, want %x", i+1, share.data, want.shares[i].data)
+		}
+	}
+
+	// And the secret still reveals.
+	dir := t.TempDir()
+	mustRunGsssa(t, dir, "annotated", "create", "--secret-stdin", "--no-print")
+	name := filepath.Join(dir, "shares.txt")
+	created, err := ioutil.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines = strings.Split(string(created), "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, "# Share ") {
+			lines[i+1] += "\n# checked twice"
+		}
+	}
+	if err := ioutil.WriteFile(name, []byte(strings.Join(lines, "\n")), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if run := mustRunGsssa(t, dir, "", "reveal", "--raw"); run.stdout != "annotated" {
+		t.Errorf("revealed %q", run.stdout)
+	}
+}
+
+// The problems found in a shares file say which line of the file, which
+// share, which of its lines and which word they are about.
+func TestParsePositions(t *testing.T) {
+	tests := []struct {
+		old, new string
+		want     string
+	}{
+		{" bamboo ", " bambxx ", `line 7 (share 1, line 2, word 2): "bambxx", did you mean: bamboo?`},
+		{" brisk ", " brisq ", `line 15 (share 3, line 2, word 26): "brisq", did you mean: brisk?`},
+		{"announce apple ", "announce ", "share 2, line 1 has 31 words, expected 32 (line 10 of the file)"},
+		{" buyer ", " buyer buyer ", "share 3, line 2 has 33 words, expected 32 (line 15 of the file)"},
+	}
+	for _, test := range tests {
+		parsed, err := testGsssa().parseSharesData(strings.Replace(readTestdata(t, "text.golden"), test.old, test.new, 1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var problems []string
+		for _, u := range parsed.unknownWords {
+			problems = append(problems, u.String())
+		}
+		for _, w := range parsed.wordCounts {
+			problems = append(problems, w.String())
+		}
+		if len(problems) != 1 || problems[0] != test.want {
+			t.Errorf("%q: %q, want %q", test.new, problems, test.want)
+		}
+	}
+
+	errs := []struct {
+		err            error
+		line, fileLine int
+		want           string
+	}{
+		{&wordError{3, errors.New("bad word")}, 2, 14, "line 14 (share 2, line 2, word 3): bad word"},
+		{&wordError{3, errors.New("bad word")}, 2, 0, "share 2, line 2, word 3: bad word"},
+		{errors.New("bad line"), 1, 9, "line 9 (share 2, line 1): bad line"},
+	}
+	for _, e := range errs {
+		if got := positionError(e.err, "share 2", e.line, e.fileLine).Error(); got != e.want {
+			t.Errorf("got %q, want %q", got, e.want)
+		}
+	}
+}
+
+// A line with words missing or too many is found by the words per line of
+// the header, or else by what the other lines have, also when they don't
+// all agree. Lines of a share with all its words are its line breaks moved.
+func TestCheckWordCounts(t *testing.T) {
+	counts := func(name string, n ...int) lineCounts {
+		return lineCounts{name: name, counts: n, fileLines: []int{10, 11, 12}}
+	}
+	tests := []struct {
+		shares  []lineCounts
+		perLine int
+		want    []string
+	}{
+		{[]lineCounts{counts("share 1", 32, 32, 5), counts("share 2", 32, 32, 5), counts("share 3", 32, 30, 5)}, 0, []string{
+			"share 3, line 2 has 30 words, expected 32 (line 11 of the file)",
+		}},
+		{[]lineCounts{counts("share 1", 32, 31, 5), counts("share 2", 32, 32, 5), counts("share 3", 30, 32, 5)}, 0, []string{
+			"share 1, line 2 has 31 words, most lines like it have 32 (line 11 of the file)",
+			"share 3, line 1 has 30 words, most lines like it have 32 (line 10 of the file)",
+		}},
+		{[]lineCounts{counts("share 1", 32, 31, 5), counts("share 2", 32, 32, 5), counts("share 3", 30, 32, 5)}, 32, []string{
+			"share 1, line 2 has 31 words, expected 32 (line 11 of the file)",
+			"share 3, line 1 has 30 words, expected 32 (line 10 of the file)",
+		}},
+		{[]lineCounts{counts("share 1", 32, 32), counts("share 2", 32, 33), counts("share 3", 32, 32)}, 32, []string{
+			"share 2, line 2 has 33 words, expected 32 (line 11 of the file)",
+		}},
+		{[]lineCounts{counts("share 1", 20, 44), counts("share 2", 32, 32), counts("share 3", 32, 32)}, 32, nil},
+		{[]lineCounts{counts("share 1", 32, 32), counts("share 2", 32, 32)}, 0, nil},
+	}
+	for i, test := range tests {
+		var got []string
+		for _, w := range checkWordCounts(test.shares, test.perLine) {
+			got = append(got, w.String())
+		}
+		if strings.Join(got, "\n") != strings.Join(test.want, "\n") {
+			t.Errorf("%d: found %q, want %q", i+1, got, test.want)
+		}
+	}
+
+	// reveal warns about them before it combines the shares.
+	dir := t.TempDir()
+	mustRunGsssa(t, dir, "counted", "create", "--secret-stdin", "--no-print")
+	name := filepath.Join(dir, "shares.txt")
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(string(data), "\n")
+	for i, line := range lines {
+		if line == "# Share 2" {
+			lines[i+1] = lines[i+1][strings.Index(lines[i+1], " ")+1:]
+		}
+	}
+	if err := ioutil.WriteFile(name, []byte(strings.Join(lines, "\n")), 0644); err != nil {
+		t.Fatal(err)
+	}
+	run := runGsssa(t, dir, "", "reveal", "--raw")
+	if want := "share 2, line 1 has 31 words, expected 32"; !strings.Contains(run.stderr, want) {
+		t.Errorf("reveal: want %s:\n%s", want, run.stderr)
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// A4 in points, and the layout of a share sheet.
+const (
+	pdfWidth      = 595
+	pdfHeight     = 842
+	pdfMargin     = 56
+	pdfWordsSize  = 14
+	pdfRowChars   = 52 // Courier is 0.6 of its size wide, so this fits the page
+	pdfLineHeight = 22
+)
+
+// pdfPage collects the drawing operators of one page.
+type pdfPage struct {
+	ops bytes.Buffer
+	y   float64
+	err error // of the first text that can't be drawn
+}
+
+// text draws s with the font ("F1" Helvetica, "F2" Helvetica-Bold, "F3"
+// Courier) at x on the current line, without moving down.
+func (p *pdfPage) text(font string, size, x float64, s string) {
+	escaped, err := pdfEscape(s)
+	if err != nil && p.err == nil {
+		p.err = err
+	}
+	fmt.Fprintf(&p.ops, "BT /%s %g Tf %g %g Td (%s) Tj ET\n", font, size, x, p.y, escaped)
+}
+
+// pdfEscape escapes s for a PDF string in the WinAnsi encoding of the
+// standard fonts. Characters it doesn't have are an error, a sheet with
+// anything else in their place couldn't be revealed from.
+func pdfEscape(s string) (string, error) {
+	var b bytes.Buffer
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= 32 && r < 127:
+			b.WriteRune(r)
+		case r >= 160 && r < 256:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			return "", fmt.Errorf("the PDF sheets can only show Latin-1 characters, not the \"%c\" of \"%s\". Leave out --pdf-dir, or use a dictionary or --lang without such characters", r, s)
+		}
+	}
+	return b.String(), nil
+}
+
+// renderPDF writes the pages as a PDF document using only the standard
+// fonts, so the text can be searched and copied.
+func renderPDF(pages []*pdfPage) []byte {
+	var out bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n")
+
+	kids := ""
+	for i := range pages {
+		kids += fmt.Sprintf("%d 0 R ", 6+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.TrimSpace(kids), len(pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>")
+	for i, p := range pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R /F2 4 0 R /F3 5 0 R >> >> /Contents %d 0 R >>", pdfWidth, pdfHeight, 7+2*i))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", p.ops.Len(), p.ops.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, o := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", o)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return out.Bytes()
+}
+
+// sharePDF is the sheet handed out with share i: its number, the threshold,
+// its lines with line numbers and fields for the holder and the date. Lines
+// that don't fit the width are continued on the next row, and a share that
+// doesn't fit on the page continues on another one.
+func sharePDF(set *shareSet, i int) ([]byte, error) {
+	share := set.shares[i]
+
+	page := &pdfPage{y: pdfHeight - pdfMargin - 20}
+	pages := []*pdfPage{page}
+	newLine := func(height float64) {
+		page.y -= height
+		if page.y < pdfMargin {
+			page = &pdfPage{y: pdfHeight - pdfMargin}
+			pages = append(pages, page)
+		}
+	}
+
+	page.text("F2", 22, pdfMargin, fmt.Sprintf("Share %d of %d", share.number, set.amount))
+	newLine(28)
+	page.text("F1", 12, pdfMargin, fmt.Sprintf("You need %d shares out of these %d shares to reveal the secret.", set.min, set.amount))
+	newLine(36)
+
+	for n, line := range share.lines {
+		label := fmt.Sprintf("%2d", n+1)
+		row := ""
+		for _, w := range line {
+			if len(row) > 0 && len(row)+1+len(w) > pdfRowChars {
+				page.text("F3", pdfWordsSize, pdfMargin, label+"  "+row)
+				newLine(pdfLineHeight)
+				label, row = "  ", ""
+			}
+			if len(row) > 0 {
+				row += " "
+			}
+			row += w
+		}
+		page.text("F3", pdfWordsSize, pdfMargin, label+"  "+row)
+		newLine(pdfLineHeight + 8)
+	}
+
+	holder := "______________________________"
+	if len(share.holder) > 0 {
+		holder = share.holder
+	}
+	newLine(24)
+	page.text("F1", 12, pdfMargin, "Holder name: "+holder)
+	newLine(30)
+	page.text("F1", 12, pdfMargin, "Date: ______________________________")
+
+	for _, p := range pages {
+		if p.err != nil {
+			return nil, p.err
+		}
+	}
+	return renderPDF(pages), nil
+}
+
+// writePDFs writes a printable PDF sheet for every share into --pdf-dir,
+// named share-<n>.pdf or share-<holder>.pdf. All of them are made before
+// any is written, so nothing is written if one can't be.
+func (g *gsssa) writePDFs(set *shareSet) {
+	var sheets [][]byte
+	for i := range set.shares {
+		sheet, err := sharePDF(set, i)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "No shares were created: %v.\n", err)
+			os.Exit(1)
+		}
+		sheets = append(sheets, sheet)
+	}
+	if err := os.MkdirAll(g.pdfDir, 0755); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	for i, share := range set.shares {
+		label := fmt.Sprint(share.number)
+		if len(share.holder) > 0 {
+			label = share.holder
+		}
+		name := filepath.Join(g.pdfDir, "share-"+label+".pdf")
+
+		if err := ioutil.WriteFile(name, sheets[i], 0644); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		g.archived = append(g.archived, archivedFile{name, "pdf/" + filepath.Base(name)})
+		fmt.Fprintf(g.messages(), "PDF sheet of share %d written to \"%s\".\n", share.number, name)
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var (
+	pdfObject = regexp.MustCompile(`(?m)^(\d+) 0 obj$`)
+	pdfWords  = regexp.MustCompile(`BT /F3 \S+ Tf \S+ \S+ Td \((.*)\) Tj ET`)
+)
+
+// checkPDF checks that sheet is a PDF document whose cross-reference table
+// points at its objects, and returns the rows of words it shows.
+func checkPDF(t *testing.T, name string, sheet []byte) []string {
+	t.Helper()
+	if !bytes.HasPrefix(sheet, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(sheet, []byte("%%EOF\n")) {
+		t.Fatalf("%s isn't a PDF document", name)
+	}
+	start := bytes.LastIndex(sheet, []byte("startxref\n"))
+	xref, err := strconv.Atoi(strings.Fields(string(sheet[start+len("startxref\n"):]))[0])
+	if err != nil || !bytes.HasPrefix(sheet[xref:], []byte("xref\n")) {
+		t.Fatalf("%s: startxref doesn't point at the xref table", name)
+	}
+	objects := pdfObject.FindAllIndex(sheet, -1)
+	entries := strings.Split(string(sheet[xref:]), "\n")[3:]
+	for i, o := range objects {
+		offset, err := strconv.Atoi(strings.Fields(entries[i])[0])
+		if err != nil || offset != o[0] {
+			t.Errorf("%s: the xref table has object %d at %s, it is at %d", name, i+1, entries[i], o[0])
+		}
+	}
+
+	var rows []string
+	for _, m := range pdfWords.FindAllSubmatch(sheet, -1) {
+		rows = append(rows, string(m[1]))
+	}
+	return rows
+}
+
+// Every sheet shows its share number, the threshold, every word of its
+// lines after the line numbers, and the fields for the holder and the date.
+func TestSharePDF(t *testing.T) {
+	set := secretSet(t, strings.Repeat("a secret too long for a row ", 8), 2, 3)
+	for i, share := range set.shares {
+		name := fmt.Sprintf("share %d", share.number)
+		sheet, err := sharePDF(set, i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows := checkPDF(t, name, sheet)
+
+		var words []string
+		for _, line := range share.lines {
+			words = append(words, line...)
+		}
+		var shown []string
+		n := 0
+		for _, row := range rows {
+			if label := strings.TrimSpace(row[:2]); len(label) > 0 {
+				n++
+				if label != strconv.Itoa(n) {
+					t.Errorf("%s: line %s, want %d", name, label, n)
+				}
+			}
+			if len(row) > pdfRowChars+4 {
+				t.Errorf("%s: row %q is wider than the page", name, row)
+			}
+			shown = append(shown, strings.Fields(row[4:])...)
+		}
+		if n != len(share.lines) || strings.Join(shown, " ") != strings.Join(words, " ") {
+			t.Errorf("%s: shows %d lines of %d words, want %d lines of %d words", name, n, len(shown), len(share.lines), len(words))
+		}
+
+		for _, text := range []string{
+			fmt.Sprintf("(Share %d of 3)", share.number),
+			"(You need 2 shares out of these 3 shares to reveal the secret.)",
+			"(Holder name: ______________________________)",
+			"(Date: ______________________________)",
+		} {
+			if !bytes.Contains(sheet, []byte(text)) {
+				t.Errorf("%s doesn't show %s", name, text)
+			}
+		}
+	}
+}
+
+// What can't be shown with the standard fonts is an error instead of a
+// sheet that couldn't be revealed from.
+func TestPDFEscape(t *testing.T) {
+	tests := []struct {
+		s, want string
+		err     bool
+	}{
+		{"abandon ability", "abandon ability", false},
+		{"(a) \\b", "\\(a\\) \\\\b", false},
+		{"über", "\\374ber", false},
+		{"あいこくしん", "", true},
+		{"a€", "", true},
+	}
+	for _, test := range tests {
+		escaped, err := pdfEscape(test.s)
+		if (err != nil) != test.err || escaped != test.want {
+			t.Errorf("%q: escaped as %q (%v), want %q", test.s, escaped, err, test.want)
+		}
+	}
+}
+
+// --pdf-dir writes a sheet for every share next to the same shares file,
+// and nothing if a word can't be shown.
+func TestCreatePDFDir(t *testing.T) {
+	dir := t.TempDir()
+	run := mustRunGsssa(t, dir, "print me", "create", "--secret-stdin", "--no-print", "--pdf-dir", "print")
+	for n := 1; n <= 3; n++ {
+		name := filepath.Join(dir, "print", fmt.Sprintf("share-%d.pdf", n))
+		sheet, err := ioutil.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rows := checkPDF(t, name, sheet); len(rows) == 0 {
+			t.Errorf("%s shows no words", name)
+		}
+		if want := fmt.Sprintf("PDF sheet of share %d written to \"print/share-%d.pdf\"", n, n); !strings.Contains(run.stdout, want) {
+			t.Errorf("the messages don't say %s:\n%s", want, run.stdout)
+		}
+	}
+	data, err := ioutil.ReadFile(filepath.Join(dir, "shares.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret := revealData(t, testGsssa(), string(data)); string(secret) != "print me" {
+		t.Errorf("the shares file revealed %q", secret)
+	}
+
+	plain := t.TempDir()
+	mustRunGsssa(t, plain, "print me", "create", "--secret-stdin", "--no-print")
+	without, err := ioutil.ReadFile(filepath.Join(plain, "shares.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a, b := strings.Count(string(data), "\n"), strings.Count(string(without), "\n"); a != b {
+		t.Errorf("the shares file has %d lines with --pdf-dir and %d without", a, b)
+	}
+
+	dir = t.TempDir()
+	run = runGsssa(t, dir, "print me", "create", "--secret-stdin", "--no-print", "--lang", "ja", "--pdf-dir", "print")
+	if run.ok || !strings.Contains(run.stderr, "the PDF sheets can only show Latin-1 characters") {
+		t.Errorf("--lang ja: ok %v, want a Latin-1 error:\n%s", run.ok, run.stderr)
+	}
+	if _, err := ioutil.ReadDir(filepath.Join(dir, "print")); err == nil {
+		t.Errorf("--lang ja: the PDF directory was made")
+	}
+}
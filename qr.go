@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+var qrLevels = map[string]qrcode.RecoveryLevel{
+	"low":     qrcode.Low,
+	"medium":  qrcode.Medium,
+	"high":    qrcode.High,
+	"highest": qrcode.Highest,
+}
+
+// shareOnly returns a copy of set holding only share i.
+func shareOnly(set *shareSet, i int) *shareSet {
+	single := *set
+	single.shares = set.shares[i : i+1]
+	// With the fingerprint of the secret its holder alone could check
+	// guesses of it.
+	single.secretFP = ""
+	return &single
+}
+
+// qrContent is what the QR code of share i holds: the share rendered as a
+// complete text shares file of its own. Scanning it and saving the text is
+// all that is needed to reveal with it.
+func qrContent(set *shareSet, i int) string {
+	return renderText(shareOnly(set, i))
+}
+
+// writeQRCodes writes a PNG QR code for every share into --qr-dir, named
+// share-<n>.png or share-<holder>.png.
+func (g *gsssa) writeQRCodes(set *shareSet) {
+	if err := os.MkdirAll(g.qrDir, 0755); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	for i, share := range set.shares {
+		label := fmt.Sprint(share.number)
+		if len(share.holder) > 0 {
+			label = share.holder
+		}
+		name := filepath.Join(g.qrDir, "share-"+label+".png")
+
+		q, err := qrcode.New(qrContent(set, i), qrLevels[g.qrLevel])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Share %d doesn't fit in a QR code: %v\n", share.number, err)
+			os.Exit(1)
+		}
+		if err := q.WriteFile(g.qrSize, name); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		g.archived = append(g.archived, archivedFile{name, "qr/" + filepath.Base(name)})
+		fmt.Fprintf(g.messages(), "QR code of share %d written to \"%s\".\n", share.number, name)
+	}
+}
+
+// qrTerminalMaxPart is the most a QR code printed on the terminal holds.
+// Larger shares are split over several QR codes, as codes much bigger than
+// this get hard to scan from a screen.
+const qrTerminalMaxPart = 600
+
+// printQRCodes reports whether QR codes should be printed on the terminal.
+func (g *gsssa) printQRCodes() bool {
+	return g.qrTerminal && isTerminal(os.Stdout)
+}
+
+// printQRCode prints the QR code of share i with Unicode half blocks. When
+// the share doesn't fit in one code it is split into numbered parts, whose
+// scanned texts put together in order are the share.
+func (g *gsssa) printQRCode(set *shareSet, i int) {
+	content := qrContent(set, i)
+	number := set.shares[i].number
+
+	parts := (len(content) + qrTerminalMaxPart - 1) / qrTerminalMaxPart
+	size := (len(content) + parts - 1) / parts
+	for p := 0; p < parts; p++ {
+		end := (p + 1) * size
+		if end > len(content) {
+			end = len(content)
+		}
+
+		q, err := qrcode.New(content[p*size:end], qrLevels[g.qrLevel])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Share %d doesn't fit in a QR code: %v\n", number, err)
+			os.Exit(1)
+		}
+		if parts > 1 {
+			fmt.Printf("QR code of share %d, part %d of %d:\n", number, p+1, parts)
+		} else {
+			fmt.Printf("QR code of share %d:\n", number)
+		}
+		fmt.Println(q.ToSmallString(false))
+	}
+}
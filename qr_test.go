@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	sssa "github.com/SSSaaS/sssa-golang"
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// secretSet is the shareSet create makes for secret, with a checksum.
+func secretSet(t *testing.T, secret string, min, amount int) *shareSet {
+	t.Helper()
+	g := testGsssa()
+	enc, err := g.shareEncoding(defaultEncoding)
+	if err != nil {
+		t.Fatal(err)
+	}
+	combined, err := sssa.Create(min, amount, packSecret(addChecksum([]byte(secret))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	set := &shareSet{min: min, amount: amount, dictionary: g.dictionaryName(), dictFP: encodingFingerprint(enc), encoding: defaultEncoding, checksum: true}
+	for i, c := range combined {
+		data, err := shareBytes(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		set.shares = append(set.shares, createdShare{number: i + 1, data: data, lines: enc.encode(data)})
+	}
+	return set
+}
+
+// The PNG of every share is the QR code of its share as a shares file of
+// its own, and any min of those files reveal the secret.
+func TestQRCodes(t *testing.T) {
+	set := secretSet(t, "scan me", 2, 3)
+	g := testGsssa()
+	g.qrDir, g.qrSize, g.qrLevel = t.TempDir(), 300, "medium"
+	g.sharesFilename = stdoutFile // for the messages
+	g.writeQRCodes(set)
+
+	var contents []string
+	for i := range set.shares {
+		content := qrContent(set, i)
+		contents = append(contents, content)
+
+		name := filepath.Join(g.qrDir, fmt.Sprintf("share-%d.png", i+1))
+		f, err := os.Open(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		img, err := png.Decode(f)
+		f.Close()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if b := img.Bounds(); b.Dx() != g.qrSize || b.Dy() != g.qrSize {
+			t.Errorf("%s is %dx%d, want %dx%d", name, b.Dx(), b.Dy(), g.qrSize, g.qrSize)
+		}
+
+		// The QR codes are made the same way every time, so the image shows
+		// that it holds content.
+		q, err := qrcode.New(content, qrLevels[g.qrLevel])
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := q.Image(g.qrSize)
+		for y := 0; y < g.qrSize; y++ {
+			for x := 0; x < g.qrSize; x++ {
+				r1, _, _, _ := img.At(x, y).RGBA()
+				r2, _, _, _ := want.At(x, y).RGBA()
+				if r1 != r2 {
+					t.Fatalf("%s isn't the QR code of share %d", name, i+1)
+				}
+			}
+		}
+
+		parsed, err := testGsssa().parseSharesData(content)
+		if err != nil {
+			t.Fatalf("the QR code of share %d: %v", i+1, err)
+		}
+		if len(parsed.shares) != 1 || parsed.shares[0].number != i+1 {
+			t.Errorf("the QR code of share %d has %d shares", i+1, len(parsed.shares))
+		}
+	}
+
+	for _, pair := range [][2]int{{0, 1}, {0, 2}, {1, 2}} {
+		if secret := revealData(t, testGsssa(), contents[pair[0]]+"\n"+contents[pair[1]]); string(secret) != "scan me" {
+			t.Errorf("shares %d and %d: revealed %q", pair[0]+1, pair[1]+1, secret)
+		}
+	}
+}
+
+// With and without the shares file, and --qr-only needs --qr-dir.
+func TestCreateQRDir(t *testing.T) {
+	tests := []struct {
+		flags     []string
+		ok        bool
+		textFile  bool
+		qrWritten bool
+	}{
+		{[]string{"--qr-dir", "qrs"}, true, true, true},
+		{[]string{"--qr-dir", "qrs", "--qr-only"}, true, false, true},
+		{[]string{"--qr-only"}, false, false, false},
+	}
+	for _, test := range tests {
+		dir := t.TempDir()
+		run := runGsssa(t, dir, "scan me", append([]string{"create", "--secret-stdin", "--no-print"}, test.flags...)...)
+		if run.ok != test.ok {
+			t.Errorf("%v: ok %v, want %v:\n%s", test.flags, run.ok, test.ok, run.stderr)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "shares.txt")); (err == nil) != test.textFile {
+			t.Errorf("%v: shares.txt written %v, want %v", test.flags, err == nil, test.textFile)
+		}
+		for n := 1; n <= 3; n++ {
+			if _, err := os.Stat(filepath.Join(dir, "qrs", fmt.Sprintf("share-%d.png", n))); (err == nil) != test.qrWritten {
+				t.Errorf("%v: share-%d.png written %v, want %v", test.flags, n, err == nil, test.qrWritten)
+			}
+		}
+	}
+}
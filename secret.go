@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// sssa splits the secret into 32 byte blocks that each have to stay below
+// its prime, and it drops all trailing zero bytes when the shares are
+// combined. That is fine for text, but not for binary data. Binary secrets
+// are therefore framed before they are split: the secret gets a 0x01
+// terminator, and every 32 byte block is made of a zero byte followed by 31
+// bytes of the secret. Text secrets are passed on untouched. Since those
+// never contain a zero byte, a combined result starting with one is always a
+// framed secret.
+const (
+	frameBlockSize  = 32
+	frameTerminator = 0x01
+)
+
+// needsFraming reports whether the secret can't be handed to sssa as it is.
+func needsFraming(secret []byte) bool {
+	return bytes.IndexByte(secret, 0) >= 0 || !utf8.Valid(secret)
+}
+
+// packSecret turns the secret into the string that is given to sssa.Create.
+func packSecret(secret []byte) string {
+	if !needsFraming(secret) {
+		return string(secret)
+	}
+
+	payload := append(append([]byte{}, secret...), frameTerminator)
+	var buff bytes.Buffer
+	for len(payload) > 0 {
+		n := frameBlockSize - 1
+		if n > len(payload) {
+			n = len(payload)
+		}
+		buff.WriteByte(0)
+		buff.Write(payload[:n])
+		payload = payload[n:]
+	}
+	return buff.String()
+}
+
+// unpackSecret undoes packSecret on the string returned by sssa.Combine.
+func unpackSecret(combined string) []byte {
+	data := []byte(combined)
+	if len(data) == 0 || data[0] != 0 {
+		return data
+	}
+
+	var secret []byte
+	for len(data) > 0 {
+		n := frameBlockSize
+		if n > len(data) {
+			n = len(data)
+		}
+		secret = append(secret, data[1:n]...)
+		data = data[n:]
+	}
+	return bytes.TrimSuffix(secret, []byte{frameTerminator})
+}
+
+// decodeSecretHex decodes a hex encoded secret. Surrounding whitespace, like
+// the newline at the end of a file, is ignored.
+func decodeSecretHex(secret []byte) ([]byte, error) {
+	decoded, err := hex.DecodeString(string(bytes.TrimSpace(secret)))
+	if err != nil {
+		return nil, fmt.Errorf("the secret is not valid hex: %v", err)
+	}
+	return decoded, nil
+}
+
+// decodeSecretBase64 decodes a base64 encoded secret. Both the standard and
+// the URL safe alphabet are accepted, with or without padding.
+func decodeSecretBase64(secret []byte) ([]byte, error) {
+	trimmed := string(bytes.TrimSpace(secret))
+	encodings := []*base64.Encoding{
+		base64.StdEncoding,
+		base64.URLEncoding,
+		base64.RawStdEncoding,
+		base64.RawURLEncoding,
+	}
+	for _, enc := range encodings {
+		if decoded, err := enc.DecodeString(trimmed); err == nil {
+			return decoded, nil
+		}
+	}
+	return nil, errors.New("the secret is not valid base64, neither in the standard nor in the URL safe alphabet")
+}
+
+// validateSecret catches secrets that are almost certainly a mistake, like an
+// unset variable in a script.
+func validateSecret(secret []byte, allowWhitespaceOnly bool) error {
+	if len(secret) == 0 {
+		return errors.New("the secret is empty. If it comes from a variable in a script, check that the variable is set")
+	}
+	if len(bytes.TrimSpace(secret)) == 0 && !allowWhitespaceOnly {
+		return errors.New("the secret only contains whitespace, which usually means it was read from the wrong place. Use --allow-whitespace-only if this really is the secret")
+	}
+	return nil
+}
+
+// With --embed-threshold every share starts with thresholdPrefixSize bytes,
+// the minimum and the amount of shares.
+const thresholdPrefixSize = 2
+
+// Shares files created with a checksum say checksum=sha256-4 in their
+// header. The checksum is the first checksumSize bytes of the SHA-256 of the
+// secret, appended to the secret before it is split.
+const checksumSize = 4
+
+// errBadChecksum is returned when the combined shares don't end with the
+// checksum of the rest of the secret.
+var errBadChecksum = errors.New("the shares did not reconstruct a valid secret. Some shares are probably wrong, mixed up or too few")
+
+// addChecksum appends the checksum to the secret.
+func addChecksum(secret []byte) []byte {
+	sum := sha256.Sum256(secret)
+	return append(append([]byte{}, secret...), sum[:checksumSize]...)
+}
+
+// verifyChecksum checks and strips the checksum added by addChecksum.
+func verifyChecksum(data []byte) ([]byte, error) {
+	if len(data) < checksumSize {
+		return nil, errBadChecksum
+	}
+	secret := data[:len(data)-checksumSize]
+	sum := sha256.Sum256(secret)
+	if !bytes.Equal(sum[:checksumSize], data[len(secret):]) {
+		return nil, errBadChecksum
+	}
+	return secret, nil
+}
+
+// The fingerprint of a secret tells whether a shares file belongs to a
+// secret without combining the shares. It is written as "salt:hash", both
+// in hex, where hash is the first fingerprintSize bytes of the SHA-256 of
+// the random salt followed by the secret.
+const (
+	fingerprintSaltSize = 8
+	fingerprintSize     = 8
+)
+
+// newFingerprint returns the fingerprint of the secret with a new salt.
+func newFingerprint(secret []byte) (string, error) {
+	salt := make([]byte, fingerprintSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	return secretFingerprint(salt, secret), nil
+}
+
+// secretFingerprint returns the fingerprint of the secret with salt.
+func secretFingerprint(salt, secret []byte) string {
+	sum := sha256.Sum256(append(append([]byte{}, salt...), secret...))
+	return hex.EncodeToString(salt) + ":" + hex.EncodeToString(sum[:fingerprintSize])
+}
+
+// matchFingerprint reports whether the secret has the fingerprint from a
+// shares file, and returns the fingerprint of the secret with its salt.
+func matchFingerprint(fingerprint string, secret []byte) (bool, string, error) {
+	parts := strings.SplitN(fingerprint, ":", 2)
+	salt, err := hex.DecodeString(parts[0])
+	if len(parts) != 2 || err != nil {
+		return false, "", fmt.Errorf("the fingerprint \"%s\" in the shares file is not valid", fingerprint)
+	}
+	computed := secretFingerprint(salt, secret)
+	return strings.EqualFold(computed, fingerprint), computed, nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	sssa "github.com/SSSaaS/sssa-golang"
+)
+
+// Random bytes, with zero bytes and invalid UTF-8, must come back exactly as
+// they were split, whatever their length.
+func TestBinarySecretRoundTrip(t *testing.T) {
+	blobs := 1000
+	if testing.Short() {
+		blobs = 100
+	}
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < blobs; i++ {
+		// Every length up to a few blocks, then longer ones up to 4 KB.
+		n := i
+		if i > 100 {
+			n = r.Intn(4096)
+		}
+		secret := make([]byte, n)
+		r.Read(secret)
+
+		combined, err := sssa.Create(2, 3, packSecret(addChecksum(secret)))
+		if err != nil {
+			t.Fatalf("%d bytes: %v", n, err)
+		}
+		// The shares go through their bytes, like in a shares file.
+		var shares []string
+		for _, c := range combined[1:] {
+			data, err := shareBytes(c)
+			if err != nil {
+				t.Fatalf("%d bytes: %v", n, err)
+			}
+			shares = append(shares, bytesToShare(data))
+		}
+
+		res, err := sssa.Combine(shares)
+		if err != nil {
+			t.Fatalf("%d bytes: %v", n, err)
+		}
+		revealed, err := verifyChecksum(unpackSecret(res))
+		if err != nil {
+			t.Fatalf("%d bytes: %v", n, err)
+		}
+		if !bytes.Equal(revealed, secret) {
+			t.Fatalf("%d bytes: revealed %x, want %x", n, revealed, secret)
+		}
+	}
+}
+
+// Empty secrets are always refused, whitespace only ones without
+// --allow-whitespace-only.
+func TestValidateSecret(t *testing.T) {
+	tests := []struct {
+		secret     string
+		whitespace bool // --allow-whitespace-only
+		ok         bool
+	}{
+		{"", false, false},
+		{"", true, false},
+		{" ", false, false},
+		{" ", true, true},
+		{"\n", false, false},
+		{"\n", true, true},
+		{"\t \r\n", false, false},
+		{"a", false, true},
+		{" a\n", false, true},
+	}
+	for _, test := range tests {
+		if err := validateSecret([]byte(test.secret), test.whitespace); (err == nil) != test.ok {
+			t.Errorf("%q with --allow-whitespace-only %v: got %v", test.secret, test.whitespace, err)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// A text shares file can hold the shares of several secrets, each in a
+// section that starts with a sectionMarker line naming it and goes on to the
+// next one. As note lines start with notePrefix, nothing else can look like
+// the start of a section.
+const sectionMarker = "# gsssa section "
+
+// sectionNames returns the names of the sections in data, in order.
+func sectionNames(data string) []string {
+	var names []string
+	for _, s := range strings.Split(data, "\n") {
+		if strings.HasPrefix(s, sectionMarker) {
+			names = append(names, strings.TrimSpace(strings.TrimPrefix(s, sectionMarker)))
+		}
+	}
+	return names
+}
+
+// section returns the lines of the section name in data.
+func section(data, name string) (string, bool) {
+	var lines []string
+	found, inside := false, false
+	for _, s := range strings.Split(data, "\n") {
+		if strings.HasPrefix(s, sectionMarker) {
+			inside = strings.TrimSpace(strings.TrimPrefix(s, sectionMarker)) == name
+			found = found || inside
+			continue
+		}
+		if inside {
+			lines = append(lines, s)
+		}
+	}
+	return strings.Join(lines, "\n"), found
+}
+
+// checkSection makes sure --section can be added to the shares file.
+func (g *gsssa) checkSection() {
+	g.section = strings.TrimSpace(g.section)
+	if len(g.section) == 0 {
+		fmt.Fprintf(os.Stderr, "The section name can't be empty.\n")
+		os.Exit(1)
+	}
+	if g.format != "text" || g.sharesFilename == stdoutFile || len(g.outputFilenames()) > 1 || len(g.archive) > 0 {
+		fmt.Fprintf(os.Stderr, "--section only works with a single shares file in the text format.\n")
+		os.Exit(1)
+	}
+
+	data, err := ioutil.ReadFile(g.sharesFilename)
+	if os.IsNotExist(err) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%+v\n", err)
+		os.Exit(1)
+	}
+
+	names := sectionNames(string(data))
+	if len(names) == 0 && len(strings.TrimSpace(string(data))) > 0 {
+		fmt.Fprintf(os.Stderr, "The shares file \"%s\" already has shares that are not in a section, so no section can be added to it.\n", g.sharesFilename)
+		os.Exit(1)
+	}
+	for _, n := range names {
+		if n == g.section {
+			fmt.Fprintf(os.Stderr, "The shares file \"%s\" already has a section \"%s\".\n", g.sharesFilename, g.section)
+			os.Exit(1)
+		}
+	}
+}
+
+// appendSection adds content as the section --section to the end of the file
+// name, and reads it back to make sure it really got there.
+func (g *gsssa) appendSection(name, content string) error {
+	old, err := ioutil.ReadFile(name)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	content = sectionMarker + g.section + "\n\n" + content
+	if len(old) > 0 {
+		content = "\n" + content
+	}
+
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	written, err := ioutil.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	if string(written) != string(old)+content {
+		return fmt.Errorf("what was read back from \"%s\" is not what was written", name)
+	}
+	return nil
+}
+
+// selectSection returns the part of a shares file to reveal. A file with
+// sections needs --section, without it the sections are listed.
+func (g *gsssa) selectSection(data string) string {
+	names := sectionNames(data)
+	if len(names) == 0 {
+		if len(g.section) > 0 {
+			fmt.Fprintf(os.Stderr, "The shares file \"%s\" has no sections.\n", g.sharesFilename)
+			os.Exit(1)
+		}
+		return data
+	}
+
+	if len(g.section) == 0 {
+		fmt.Fprintf(os.Stderr, "The shares file \"%s\" has the sections \"%s\". Choose one with --section.\n", g.sharesFilename, strings.Join(names, "\", \""))
+		os.Exit(1)
+	}
+	part, ok := section(data, g.section)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "The shares file \"%s\" has no section \"%s\", only \"%s\".\n", g.sharesFilename, g.section, strings.Join(names, "\", \""))
+		os.Exit(1)
+	}
+	return part
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// A section goes on to the next one, and its name is without the spaces
+// around it.
+func TestSections(t *testing.T) {
+	data := "# gsssa section bank\n\nbank words\n\n# gsssa section  wallet \n\nwallet words\n"
+	if names := sectionNames(data); strings.Join(names, ",") != "bank,wallet" {
+		t.Errorf("sections %q, want bank and wallet", names)
+	}
+	tests := []struct {
+		name, want string
+		found      bool
+	}{
+		{"bank", "\nbank words\n", true},
+		{"wallet", "\nwallet words\n", true},
+		{"safe", "", false},
+	}
+	for _, test := range tests {
+		if part, found := section(data, test.name); part != test.want || found != test.found {
+			t.Errorf("%s: %q, found %v", test.name, part, found)
+		}
+	}
+
+	// appendSection adds to what is there already.
+	name := filepath.Join(t.TempDir(), "shares.txt")
+	g := testGsssa()
+	for _, s := range []string{"bank", "wallet"} {
+		g.section = s
+		if err := g.appendSection(name, s+" words\n"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	written, err := ioutil.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "# gsssa section bank\n\nbank words\n\n# gsssa section wallet\n\nwallet words\n"; string(written) != want {
+		t.Errorf("wrote %q, want %q", written, want)
+	}
+}
+
+// create --section adds the shares of another secret to a file, and reveal
+// --section reveals one of them.
+func TestCreateSections(t *testing.T) {
+	dir := t.TempDir()
+	for _, s := range []string{"bank", "wallet"} {
+		mustRunGsssa(t, dir, s+" secret", "create", "--secret-stdin", "--no-print", "--section", s)
+	}
+	for _, s := range []string{"bank", "wallet"} {
+		if run := mustRunGsssa(t, dir, "", "reveal", "--raw", "--section", s); run.stdout != s+" secret" {
+			t.Errorf("--section %s: revealed %q", s, run.stdout)
+		}
+	}
+
+	tests := []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"reveal"}, "has the sections \"bank\", \"wallet\". Choose one with --section."},
+		{[]string{"reveal", "--section", "safe"}, "has no section \"safe\", only \"bank\", \"wallet\"."},
+		{[]string{"create", "--secret-stdin", "--no-print", "--section", "bank"}, "already has a section \"bank\"."},
+		{[]string{"create", "--secret-stdin", "--no-print", "--section", " "}, "The section name can't be empty."},
+		{[]string{"create", "--secret-stdin", "--no-print", "--section", "safe", "--format", "json"}, "--section only works with a single shares file in the text format."},
+	}
+	for _, test := range tests {
+		if run := runGsssa(t, dir, "again", test.args...); run.ok || !strings.Contains(run.stderr, test.err) {
+			t.Errorf("%v: ok %v, stderr %q", test.args, run.ok, run.stderr)
+		}
+	}
+
+	// A file without sections can't get one, or be revealed with one.
+	plain := t.TempDir()
+	mustRunGsssa(t, plain, "no sections", "create", "--secret-stdin", "--no-print")
+	if run := runGsssa(t, plain, "again", "create", "--secret-stdin", "--no-print", "--section", "bank"); run.ok || !strings.Contains(run.stderr, "already has shares that are not in a section") {
+		t.Errorf("adding a section: ok %v, stderr %q", run.ok, run.stderr)
+	}
+	if run := runGsssa(t, plain, "", "reveal", "--section", "bank"); run.ok || !strings.Contains(run.stderr, "has no sections.") {
+		t.Errorf("reveal --section: ok %v, stderr %q", run.ok, run.stderr)
+	}
+}
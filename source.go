@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// secretSource describes where create gets the secret from. At most one
+// source can be chosen, without any the secret is asked for on the terminal.
+type secretSource struct {
+	arg      string
+	stdin    bool
+	file     string
+	env      string
+	cmd      string
+	json     string
+	yaml     string
+	generate int
+
+	noConfirm    bool
+	argVisibleOK bool
+
+	keepNewline  bool
+	stripNewline bool
+
+	messages io.Writer // where the generated secret is shown
+}
+
+// trailingNewline applies --keep-trailing-newline and
+// --strip-trailing-newline to what was read from stdin, a file or a command.
+// Without either flag, one trailing newline is stripped when stripByDefault
+// is set. Only a single newline ("\n" or "\r\n") is ever removed, so a
+// secret that really ends in newlines keeps all but the last one.
+func (s *secretSource) trailingNewline(data []byte, stripByDefault bool) []byte {
+	if s.keepNewline || (!s.stripNewline && !stripByDefault) {
+		return data
+	}
+	if !bytes.HasSuffix(data, []byte("\n")) {
+		return data
+	}
+	data = data[:len(data)-1]
+	return bytes.TrimSuffix(data, []byte("\r"))
+}
+
+// chosen returns the names of the sources given on the command line.
+func (s *secretSource) chosen() []string {
+	var names []string
+	if len(s.arg) > 0 {
+		names = append(names, "the secret argument")
+	}
+	if s.stdin {
+		names = append(names, "--secret-stdin")
+	}
+	if len(s.file) > 0 {
+		names = append(names, "--secret-file")
+	}
+	if len(s.env) > 0 {
+		names = append(names, "--secret-env")
+	}
+	if len(s.cmd) > 0 {
+		names = append(names, "--secret-cmd")
+	}
+	if len(s.json) > 0 {
+		names = append(names, "--secret-json")
+	}
+	if len(s.yaml) > 0 {
+		names = append(names, "--secret-yaml")
+	}
+	if s.generate > 0 {
+		names = append(names, "--generate")
+	}
+	return names
+}
+
+// stdinSource names the chosen source that reads from stdin, empty if none
+// of them do. Without any source the secret is prompted for on stdin.
+func (s *secretSource) stdinSource() string {
+	switch {
+	case s.stdin:
+		return "--secret-stdin"
+	case len(s.cmd) > 0:
+		return "--secret-cmd"
+	case len(s.chosen()) == 0:
+		return "the secret prompt"
+	}
+	return ""
+}
+
+// read returns the secret from the chosen source.
+func (s *secretSource) read() []byte {
+	if s.keepNewline && s.stripNewline {
+		fmt.Fprintf(os.Stderr, "Only one of --keep-trailing-newline and --strip-trailing-newline can be used.\n")
+		os.Exit(1)
+	}
+
+	chosen := s.chosen()
+	if len(chosen) > 1 {
+		fmt.Fprintf(os.Stderr, "Only one secret source can be used, but got %s.\n", strings.Join(chosen, ", "))
+		os.Exit(1)
+	}
+
+	switch {
+	case s.stdin:
+		return s.trailingNewline(readSecretFromStdin(), true)
+	case len(s.file) > 0:
+		data, err := ioutil.ReadFile(s.file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%+v\n", err)
+			os.Exit(1)
+		}
+		if len(data) == 0 {
+			fmt.Fprintf(os.Stderr, "The secret file \"%s\" is empty.\n", s.file)
+			os.Exit(1)
+		}
+		return s.trailingNewline(data, false)
+	case len(s.env) > 0:
+		value := os.Getenv(s.env)
+		os.Unsetenv(s.env)
+		if len(value) == 0 {
+			fmt.Fprintf(os.Stderr, "The environment variable \"%s\" is not set or empty.\n", s.env)
+			os.Exit(1)
+		}
+		return []byte(value)
+	case len(s.cmd) > 0:
+		secret := s.trailingNewline(readSecretFromCommand(s.cmd), true)
+		if len(secret) == 0 {
+			fmt.Fprintf(os.Stderr, "The secret command \"%s\" didn't output anything.\n", s.cmd)
+			os.Exit(1)
+		}
+		return secret
+	case len(s.json) > 0 || len(s.yaml) > 0:
+		spec, isYAML := s.json, false
+		if len(s.yaml) > 0 {
+			spec, isYAML = s.yaml, true
+		}
+		secret, err := readStructuredField(spec, isYAML)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return secret
+	case s.generate > 0:
+		secret := make([]byte, s.generate)
+		if _, err := rand.Read(secret); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(s.messages, "Generated secret (hex): %s\n", hex.EncodeToString(secret))
+		fmt.Fprintf(s.messages, "Generated secret (base64): %s\n", base64.StdEncoding.EncodeToString(secret))
+		fmt.Fprintf(s.messages, "This is the only time the secret is shown. Reveal it later with --secret-hex or --secret-base64.\n\n")
+		return secret
+	case len(s.arg) > 0:
+		if !s.argVisibleOK {
+			fmt.Fprintf(os.Stderr, "WARNING: The secret was given as an argument. Other users on this machine can see it in ps and /proc, and it is probably in your shell history now. Leave it out to be prompted, or use --secret-stdin, --secret-file, --secret-env or --secret-cmd. Use --i-know-argv-is-visible to silence this warning.\n\n")
+		}
+		return []byte(s.arg)
+	}
+
+	if !isTerminal(os.Stdin) {
+		fmt.Fprintf(os.Stderr, "No secret given. Use --secret-stdin, --secret-file, --secret-env, --secret-cmd, --secret-json, --secret-yaml or --generate, or run on a terminal to be prompted for it.\n")
+		os.Exit(1)
+	}
+
+	secret := promptSecret("Secret: ")
+	if !s.noConfirm && !bytes.Equal(promptSecret("Repeat secret: "), secret) {
+		fmt.Fprintf(os.Stderr, "The secrets entered do not match. No shares were created.\n")
+		os.Exit(1)
+	}
+	return secret
+}
+
+// promptSecret asks for the secret on the terminal without echoing what is
+// typed.
+func promptSecret(prompt string) []byte {
+	fmt.Fprint(os.Stderr, prompt)
+	data, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%+v\n", err)
+		os.Exit(1)
+	}
+	return data
+}
+
+// readSecretFromStdin reads the secret from standard input until EOF.
+func readSecretFromStdin() []byte {
+	if isTerminal(os.Stdin) {
+		fmt.Fprintf(os.Stderr, "Enter the secret and finish with Ctrl-D:\n")
+	}
+
+	data, err := ioutil.ReadAll(os.Stdin)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%+v\n", err)
+		os.Exit(1)
+	}
+	return data
+}
+
+// readSecretFromCommand runs the command with the shell and returns what it
+// writes to stdout. The command
+// gets the terminal for stdin and stderr, so password managers can ask for
+// their passphrase.
+func readSecretFromCommand(command string) []byte {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = os.Stderr
+
+	data, err := cmd.Output()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "The secret command \"%s\" failed: %v\n", command, err)
+		os.Exit(1)
+	}
+	return data
+}
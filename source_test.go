@@ -0,0 +1,57 @@
+package main
+
+import "testing"
+
+// One trailing newline is removed from stdin and commands by default, and
+// from files only with --strip-trailing-newline. Only ever one is removed.
+func TestTrailingNewline(t *testing.T) {
+	tests := []struct {
+		data           string
+		keep, strip    bool
+		stripByDefault bool
+		want           string
+	}{
+		{"secret\n", false, false, true, "secret"},
+		{"secret\r\n", false, false, true, "secret"},
+		{"secret\n\n", false, false, true, "secret\n"},
+		{"secret\r\n\r\n", false, false, true, "secret\r\n"},
+		{"secret", false, false, true, "secret"},
+		{"secret\r", false, false, true, "secret\r"},
+		{"secret\n", true, false, true, "secret\n"},
+		{"secret\n", false, false, false, "secret\n"},
+		{"secret\n", false, true, false, "secret"},
+		{"secret\n\n\n", false, true, false, "secret\n\n"},
+	}
+	for _, test := range tests {
+		s := &secretSource{keepNewline: test.keep, stripNewline: test.strip}
+		if got := string(s.trailingNewline([]byte(test.data), test.stripByDefault)); got != test.want {
+			t.Errorf("%q, keep %v, strip %v, strip by default %v: got %q, want %q", test.data, test.keep, test.strip, test.stripByDefault, got, test.want)
+		}
+	}
+}
+
+// Every source create reads the secret from applies the flags the same way.
+func TestTrailingNewlineSources(t *testing.T) {
+	file := writeTestFile(t, "secret.txt", "secret\n\n")
+	tests := []struct {
+		flags []string
+		stdin string
+		want  string
+	}{
+		{[]string{"--secret-stdin"}, "secret\n\n", "secret\n"},
+		{[]string{"--secret-stdin", "--keep-trailing-newline"}, "secret\n\n", "secret\n\n"},
+		{[]string{"--secret-file", file}, "", "secret\n\n"},
+		{[]string{"--secret-file", file, "--strip-trailing-newline"}, "", "secret\n"},
+		{[]string{"--secret-cmd", "printf 'secret\\n\\n'"}, "", "secret\n"},
+		{[]string{"--secret-cmd", "printf 'secret\\n\\n'", "--keep-trailing-newline"}, "", "secret\n\n"},
+	}
+	for _, test := range tests {
+		if got := revealCreated(t, test.stdin, test.flags, nil); got != test.want {
+			t.Errorf("%v: revealed %q, want %q", test.flags, got, test.want)
+		}
+	}
+
+	if run := runGsssa(t, t.TempDir(), "secret", "create", "--no-print", "--secret-stdin", "--keep-trailing-newline", "--strip-trailing-newline"); run.ok {
+		t.Errorf("both --keep-trailing-newline and --strip-trailing-newline were accepted")
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// splitFieldSpec splits "file.json:.path.to.field" into the file name and the
+// path. The path is everything after the last colon, so Windows drive letters
+// in the file name still work.
+func splitFieldSpec(spec string) (string, string, error) {
+	i := strings.LastIndex(spec, ":")
+	if i <= 0 || !strings.HasPrefix(spec[i+1:], ".") {
+		return "", "", fmt.Errorf("\"%s\" should look like file:.path.to.field", spec)
+	}
+	return spec[:i], spec[i+1:], nil
+}
+
+// readStructuredField reads a JSON or YAML file and returns the string found
+// at path. Path segments are separated by dots, numeric segments index
+// lists, e.g. ".data.keys.0.value".
+func readStructuredField(spec string, isYAML bool) ([]byte, error) {
+	filename, path, err := splitFieldSpec(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("can't read the secret file: %v", err)
+	}
+
+	var doc interface{}
+	if isYAML {
+		err = yaml.Unmarshal(data, &doc)
+	} else {
+		err = json.Unmarshal(data, &doc)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can't parse \"%s\": %v", filename, err)
+	}
+
+	value := doc
+	walked := ""
+	for _, key := range strings.Split(strings.TrimPrefix(path, "."), ".") {
+		if len(key) == 0 {
+			continue
+		}
+		walked += "." + key
+
+		var found bool
+		switch node := value.(type) {
+		case map[string]interface{}:
+			value, found = node[key]
+		case map[interface{}]interface{}:
+			value, found = node[key]
+		case []interface{}:
+			if i, err := strconv.Atoi(key); err == nil && i >= 0 && i < len(node) {
+				value, found = node[i], true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("there is no field \"%s\" in \"%s\"", walked, filename)
+		}
+	}
+
+	s, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("the field \"%s\" in \"%s\" is not a string but %s", path, filename, describeValue(value))
+	}
+	return []byte(s), nil
+}
+
+// describeValue names the kind of a decoded JSON or YAML value for error
+// messages.
+func describeValue(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "a boolean"
+	case float64, int, int64, uint64:
+		return "a number"
+	case []interface{}:
+		return "a list"
+	case map[string]interface{}, map[interface{}]interface{}:
+		return "an object"
+	}
+	return fmt.Sprintf("a %T", value)
+}
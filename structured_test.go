@@ -0,0 +1,62 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// The errors of --secret-json and --secret-yaml tell the missing file, the
+// missing field and the field that isn't a string apart.
+func TestReadStructuredField(t *testing.T) {
+	state := writeTestFile(t, "state.json", `{"outputs": {"root_password": {"value": "hunter2"}, "port": {"value": 22}}, "keys": [{"value": "first"}, {"value": "second"}]}`)
+	manifest := writeTestFile(t, "secret.yaml", "data:\n  password: \"s3cret\"\n  enabled: true\n  list:\n    - one\n    - two\n")
+	missing := filepath.Join(t.TempDir(), "missing.json")
+
+	tests := []struct {
+		spec   string
+		yaml   bool
+		secret string
+		err    string // in the error, if there is one
+	}{
+		{state + ":.outputs.root_password.value", false, "hunter2", ""},
+		{state + ":.keys.1.value", false, "second", ""},
+		{manifest + ":.data.password", true, "s3cret", ""},
+		{manifest + ":.data.list.0", true, "one", ""},
+		{missing + ":.value", false, "", "can't read the secret file"},
+		{state, false, "", "should look like file:.path.to.field"},
+		{state + ":.outputs.password.value", false, "", "no field \".outputs.password\""},
+		{state + ":.keys.2.value", false, "", "no field \".keys.2\""},
+		{state + ":.outputs.port.value", false, "", "not a string but a number"},
+		{state + ":.outputs", false, "", "not a string but an object"},
+		{manifest + ":.data.enabled", true, "", "not a string but a boolean"},
+		{manifest + ":.data.list", true, "", "not a string but a list"},
+		{manifest + ":.data.password", false, "", "can't parse"},
+	}
+	for _, test := range tests {
+		secret, err := readStructuredField(test.spec, test.yaml)
+		switch {
+		case len(test.err) == 0 && err != nil:
+			t.Errorf("%s: %v", test.spec, err)
+		case len(test.err) == 0 && string(secret) != test.secret:
+			t.Errorf("%s: got %q, want %q", test.spec, secret, test.secret)
+		case len(test.err) > 0 && (err == nil || !strings.Contains(err.Error(), test.err)):
+			t.Errorf("%s: got %v, want an error with %q", test.spec, err, test.err)
+		}
+	}
+}
+
+// The field that is read is the secret that is revealed.
+func TestSecretFieldRoundTrip(t *testing.T) {
+	state := writeTestFile(t, "state.json", `{"outputs": {"root_password": {"value": "from json"}}}`)
+	manifest := writeTestFile(t, "secret.yaml", "data:\n  password: from yaml\n")
+	if secret := revealCreated(t, "", []string{"--secret-json", state + ":.outputs.root_password.value"}, nil); secret != "from json" {
+		t.Errorf("--secret-json: revealed %q", secret)
+	}
+	if secret := revealCreated(t, "", []string{"--secret-yaml", manifest + ":.data.password"}, nil); secret != "from yaml" {
+		t.Errorf("--secret-yaml: revealed %q", secret)
+	}
+	if run := runGsssa(t, t.TempDir(), "", "create", "--no-print", "--secret-json", state+":.outputs.missing"); run.ok || !strings.Contains(run.stderr, "no field") {
+		t.Errorf("a missing field: ok %v, stderr %q", run.ok, run.stderr)
+	}
+}
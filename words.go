@@ -1,262 +1,59 @@
 package main
 
-var (
-	embeddedWords = []string{
-		"abandon",
-		"ability",
-		"able",
-		"about",
-		"above",
-		"absent",
-		"absorb",
-		"abstract",
-		"absurd",
-		"abuse",
-		"access",
-		"accident",
-		"account",
-		"accuse",
-		"achieve",
-		"acid",
-		"acoustic",
-		"acquire",
-		"across",
-		"act",
-		"action",
-		"actor",
-		"actress",
-		"actual",
-		"adapt",
-		"add",
-		"addict",
-		"address",
-		"adjust",
-		"admit",
-		"adult",
-		"advance",
-		"advice",
-		"aerobic",
-		"affair",
-		"afford",
-		"afraid",
-		"again",
-		"age",
-		"agent",
-		"agree",
-		"ahead",
-		"aim",
-		"air",
-		"airport",
-		"aisle",
-		"alarm",
-		"album",
-		"alcohol",
-		"alert",
-		"alien",
-		"all",
-		"alley",
-		"allow",
-		"almost",
-		"alone",
-		"alpha",
-		"already",
-		"also",
-		"alter",
-		"always",
-		"amateur",
-		"amazing",
-		"among",
-		"amount",
-		"amused",
-		"analyst",
-		"anchor",
-		"ancient",
-		"anger",
-		"angle",
-		"angry",
-		"animal",
-		"ankle",
-		"announce",
-		"annual",
-		"another",
-		"answer",
-		"antenna",
-		"antique",
-		"anxiety",
-		"any",
-		"apart",
-		"apology",
-		"appear",
-		"apple",
-		"approve",
-		"april",
-		"arch",
-		"arctic",
-		"area",
-		"arena",
-		"argue",
-		"arm",
-		"armed",
-		"armor",
-		"army",
-		"around",
-		"arrange",
-		"arrest",
-		"arrive",
-		"arrow",
-		"art",
-		"artefact",
-		"artist",
-		"artwork",
-		"ask",
-		"aspect",
-		"assault",
-		"asset",
-		"assist",
-		"assume",
-		"asthma",
-		"athlete",
-		"atom",
-		"attack",
-		"attend",
-		"attitude",
-		"attract",
-		"auction",
-		"audit",
-		"august",
-		"aunt",
-		"author",
-		"auto",
-		"autumn",
-		"average",
-		"avocado",
-		"avoid",
-		"awake",
-		"aware",
-		"away",
-		"awesome",
-		"awful",
-		"awkward",
-		"axis",
-		"baby",
-		"bachelor",
-		"bacon",
-		"badge",
-		"bag",
-		"balance",
-		"balcony",
-		"ball",
-		"bamboo",
-		"banana",
-		"banner",
-		"bar",
-		"barely",
-		"bargain",
-		"barrel",
-		"base",
-		"basic",
-		"basket",
-		"battle",
-		"beach",
-		"bean",
-		"beauty",
-		"because",
-		"become",
-		"beef",
-		"before",
-		"begin",
-		"behave",
-		"behind",
-		"believe",
-		"below",
-		"belt",
-		"bench",
-		"benefit",
-		"best",
-		"betray",
-		"better",
-		"between",
-		"beyond",
-		"bicycle",
-		"bid",
-		"bike",
-		"bind",
-		"biology",
-		"bird",
-		"birth",
-		"bitter",
-		"black",
-		"blade",
-		"blame",
-		"blanket",
-		"blast",
-		"bleak",
-		"bless",
-		"blind",
-		"blood",
-		"blossom",
-		"blouse",
-		"blue",
-		"blur",
-		"blush",
-		"board",
-		"boat",
-		"body",
-		"boil",
-		"bomb",
-		"bone",
-		"bonus",
-		"book",
-		"boost",
-		"border",
-		"boring",
-		"borrow",
-		"boss",
-		"bottom",
-		"bounce",
-		"box",
-		"boy",
-		"bracket",
-		"brain",
-		"brand",
-		"brass",
-		"brave",
-		"bread",
-		"breeze",
-		"brick",
-		"bridge",
-		"brief",
-		"bright",
-		"bring",
-		"brisk",
-		"broccoli",
-		"broken",
-		"bronze",
-		"broom",
-		"brother",
-		"brown",
-		"brush",
-		"bubble",
-		"buddy",
-		"budget",
-		"buffalo",
-		"build",
-		"bulb",
-		"bulk",
-		"bullet",
-		"bundle",
-		"bunker",
-		"burden",
-		"burger",
-		"burst",
-		"bus",
-		"business",
-		"busy",
-		"butter",
-		"buyer",
-		"buzz",
-		"cabbage",
-		"cabin",
-		"cable",
-	}
+import (
+	"embed"
+	"fmt"
+	"sort"
+	"strings"
 )
+
+// wordlists holds the built-in dictionaries, one word per line, used without
+// --dictionary. They are built into the binary, so a reveal never depends on
+// finding the word list file again. The English, Spanish, French, Italian and
+// Japanese ones are the first 256 words of the BIP-39 lists.
+//
+//go:embed wordlists/*.txt
+var wordlists embed.FS
+
+// languages maps the --lang codes to the files of their built-in word lists.
+var languages = map[string]string{
+	"en": "english.txt",
+	"es": "spanish.txt",
+	"fr": "french.txt",
+	"it": "italian.txt",
+	"de": "german.txt",
+	"pt": "portuguese.txt",
+	"ja": "japanese.txt",
+}
+
+// defaultLanguage is the word list used without --lang. Shares files only
+// name the language when it is another one.
+const defaultLanguage = "en"
+
+// prefixLength is how many letters at the start of every word of a
+// built-in word list are different from those of all the other words.
+const prefixLength = 4
+
+// languageCodes returns the --lang codes, sorted.
+func languageCodes() []string {
+	var codes []string
+	for code := range languages {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// embeddedWords returns the built-in word list of lang, without the newline
+// at its end.
+func embeddedWords(lang string) (string, error) {
+	name, ok := languages[lang]
+	if !ok {
+		return "", fmt.Errorf("there is no built-in word list for the language \"%s\", only for %s", lang, strings.Join(languageCodes(), ", "))
+	}
+	data, err := wordlists.ReadFile("wordlists/" + name)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(string(data), "\n"), nil
+}